@@ -0,0 +1,61 @@
+package main
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/sniperkit/pluck/pkg/bench"
+	"github.com/urfave/cli"
+)
+
+// benchCommand compares the output of two benchmark runs.
+var benchCommand = cli.Command{
+	Name:      "bench",
+	Usage:     "compare benchmark results against a baseline",
+	ArgsUsage: "BASELINE CURRENT",
+	Description: `BASELINE and CURRENT are outputs of 'go test -bench', e.g.
+   tests/bench_baseline.txt and bench_output.txt (see 'make bench').
+   Exits with status 1 if a benchmark is slower than the baseline
+   by more than the threshold.`,
+	Flags: []cli.Flag{
+		cli.Float64Flag{
+			Name:  "threshold",
+			Value: 10,
+			Usage: "slowdown in percent above which a benchmark regressed",
+		},
+	},
+	Action: func(c *cli.Context) error {
+		if c.NArg() != 2 {
+			return cli.NewExitError("Must specify the baseline and the current results.\nSee help and usage with -h", 2)
+		}
+		base, err := readBenchmarks(c.Args().Get(0))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 2)
+		}
+		current, err := readBenchmarks(c.Args().Get(1))
+		if err != nil {
+			return cli.NewExitError(err.Error(), 2)
+		}
+		deltas := bench.Compare(base, current, c.Float64("threshold"))
+		bench.Report(os.Stdout, deltas)
+		regressions := 0
+		for _, d := range deltas {
+			if d.Regression {
+				regressions++
+			}
+		}
+		if regressions > 0 {
+			return cli.NewExitError(fmt.Sprintf("%d benchmark(s) regressed by more than %g%%", regressions, c.Float64("threshold")), 1)
+		}
+		return nil
+	},
+}
+
+func readBenchmarks(name string) ([]bench.Result, error) {
+	f, err := os.Open(name)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return bench.Parse(f)
+}
@@ -0,0 +1,100 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/sniperkit/pluck/pkg/config"
+	"github.com/urfave/cli"
+)
+
+// configCommand gathers the commands working on config files.
+var configCommand = cli.Command{
+	Name:  "config",
+	Usage: "work with config files",
+	Subcommands: []cli.Command{
+		{
+			Name:      "export",
+			Usage:     "convert a config file to another format",
+			ArgsUsage: "[FILE]",
+			Description: `Writes FILE, or else the config files found in ./conf, ~/.plucker
+   or the XDG config directory once merged, to the standard output in
+   the given format, the fields not set in the file having their default.`,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format",
+					Value: "yaml",
+					Usage: "format to export to: toml, yaml, json, xml or ini",
+				},
+				cli.StringFlag{
+					Name:  "config-format",
+					Value: "",
+					Usage: "format of FILE, if neither its extension nor its content tell it",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				conf, err := readConfig(c)
+				if err != nil {
+					return cli.NewExitError(err.Error(), 2)
+				}
+				if err = conf.Export(os.Stdout, c.String("format")); err != nil {
+					return cli.NewExitError(err.Error(), 2)
+				}
+				return nil
+			},
+		},
+		{
+			Name:  "schema",
+			Usage: "print the JSON Schema of config files",
+			Description: `The schema describes the keys of JSON config files (YAML and TOML
+   ones use the same keys), for editors to complete and check them.`,
+			Action: func(c *cli.Context) error {
+				enc := json.NewEncoder(os.Stdout)
+				enc.SetIndent("", "    ")
+				enc.SetEscapeHTML(false)
+				if err := enc.Encode(config.NewSchema()); err != nil {
+					return cli.NewExitError(err.Error(), 2)
+				}
+				return nil
+			},
+		},
+		{
+			Name:      "validate",
+			Usage:     "check a config file against the schema, and its pluckers",
+			ArgsUsage: "FILE",
+			Description: `Reports every problem of FILE, with the path of the value at fault
+   and its line when known. Exits with status 1 if FILE is invalid.`,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "config-format",
+					Value: "",
+					Usage: "format of FILE, if neither its extension nor its content tell it",
+				},
+			},
+			Action: func(c *cli.Context) error {
+				if c.NArg() != 1 {
+					return cli.NewExitError("Must specify the config file to validate.\nSee help and usage with -h", 2)
+				}
+				if err := config.ValidateFile(c.Args().First(), c.String("config-format")); err != nil {
+					return cli.NewExitError(err.Error(), 1)
+				}
+				fmt.Println(c.Args().First() + " is valid")
+				return nil
+			},
+		},
+	},
+}
+
+// readConfig reads the config file given as argument, or else the ones found.
+func readConfig(c *cli.Context) (*config.Configs, error) {
+	if c.NArg() > 0 {
+		return config.ParseFile(c.Args().First(), c.String("config-format"))
+	}
+	conf, _, err := config.Discover()
+	if err == nil && conf == nil {
+		err = cli.NewExitError("no config file found in "+strings.Join(config.ConfigDirs(), ", "), 2)
+	}
+	return conf, err
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"sort"
 	"strings"
 	"time"
 
+	"github.com/pkg/errors"
 	"github.com/sniperkit/pluck/pkg/config"
 	"github.com/sniperkit/pluck/pkg/pluck"
 	"github.com/urfave/cli"
@@ -21,8 +23,8 @@ func main() {
 	app.Name = "pluck"
 	app.Usage = ""
 	app.UsageText = `	
-1) Pluck all URLs from a website
-$ pluck -a '<' -a 'href' -a '"' -d '"' -l -1 -u https://nytimes.com
+1) Pluck all URLs from a website, as absolute URLs, once each
+$ pluck -a '<' -a 'href' -a '"' -d '"' -l -1 --resolve --unique -u https://nytimes.com
 
 2) Pluck title from a HTML file
 $ pluck -a '<title>' -d '<' -f test.html
@@ -59,10 +61,33 @@ $ pluck -a 'Section 2' -a '<a' -a 'href' -a '"' -d '"' -p 1 -finisher "Section 3
 			Value: "",
 			Usage: "url to pluck",
 		},
+		cli.StringSliceFlag{
+			Name:  "header,H",
+			Usage: "header sent with the url, as 'Key: Value' (can specify multiple times)",
+		},
+		cli.DurationFlag{
+			Name:  "timeout",
+			Usage: "maximum time to download the url (no limit if not set)",
+		},
+		cli.StringFlag{
+			Name:  "proxy",
+			Value: "",
+			Usage: "url of the proxy to download through",
+		},
+		cli.IntFlag{
+			Name:  "retries",
+			Value: 0,
+			Usage: "number of retries when downloading the url fails",
+		},
 		cli.StringFlag{
 			Name:  "config,c",
 			Value: "",
-			Usage: "specify toml config file",
+			Usage: "specify config file (toml, yaml, json, xml or ini), instead of the ones found in ./conf, ~/.plucker or the XDG config directory",
+		},
+		cli.StringFlag{
+			Name:  "config-format",
+			Value: "",
+			Usage: "format of the config file, if neither its extension nor its content tell it",
 		},
 		cli.StringSliceFlag{
 			Name:  "activator,a",
@@ -73,6 +98,11 @@ $ pluck -a 'Section 2' -a '<a' -a 'href' -a '"' -d '"' -p 1 -finisher "Section 3
 			Value: "",
 			Usage: "text to find to restart capturing",
 		},
+		cli.StringFlag{
+			Name:  "record",
+			Value: "",
+			Usage: "text starting each record, instead of activators and a deactivator",
+		},
 		cli.IntFlag{
 			Name:  "permanent,p",
 			Value: 0,
@@ -88,6 +118,32 @@ $ pluck -a 'Section 2' -a '<a' -a 'href' -a '"' -d '"' -p 1 -finisher "Section 3
 			Value: -1,
 			Usage: "maximum number of items to capture",
 		},
+		cli.BoolFlag{
+			Name:  "regex,r",
+			Usage: "activators, deactivator and finisher are regular expressions",
+		},
+		cli.BoolFlag{
+			Name:  "unique",
+			Usage: "drop the captures already found (the limit then counts distinct captures)",
+		},
+		cli.BoolFlag{
+			Name:  "unique-normalized",
+			Usage: "drop the captures already found, whatever their white space and case",
+		},
+		cli.BoolFlag{
+			Name:  "resolve",
+			Usage: "resolve the captures as URLs against the URL plucked",
+		},
+		cli.StringFlag{
+			Name:  "type",
+			Value: "",
+			Usage: "type the captures are converted to: string, int, float, bool, date or url",
+		},
+		cli.StringFlag{
+			Name:  "format",
+			Value: "",
+			Usage: "layout of dates, or decimal separator of numbers",
+		},
 		cli.BoolFlag{
 			Name:  "sanitize,s",
 			Usage: "sanitize output (html tag stripping and hex conversion)",
@@ -96,6 +152,10 @@ $ pluck -a 'Section 2' -a '<a' -a 'href' -a '"' -d '"' -p 1 -finisher "Section 3
 			Name:  "text, t",
 			Usage: "output as plain text, not JSON",
 		},
+		cli.BoolFlag{
+			Name:  "positions",
+			Usage: "output the offset, line and column of each capture",
+		},
 		cli.BoolFlag{
 			Name:  "verbose",
 			Usage: "turn on verbose mode",
@@ -112,30 +172,64 @@ $ pluck -a 'Section 2' -a '<a' -a 'href' -a '"' -d '"' -p 1 -finisher "Section 3
 			fmt.Println("Must specify file or url. For example -u https://nytimes.com.\nSee help and usage with -h")
 			return nil
 		}
-		p, _ := pluck.New()
+		options, err := clientOptions(c)
+		if err != nil {
+			return err
+		}
+		p, err := pluck.New(options...)
+		if err != nil {
+			return err
+		}
 		if c.GlobalBool("verbose") {
 			p.Verbose(true)
 		}
+		p.Positions(c.GlobalBool("positions"))
 		if len(c.GlobalString("config")) > 0 {
-			p.Load(c.GlobalString("config"))
-		} else {
-			if len(c.GlobalStringSlice("activator")) == 0 {
+			if err = loadConfig(p, c.GlobalString("config"), c.GlobalString("config-format")); err != nil {
+				return err
+			}
+		} else if len(c.GlobalStringSlice("activator")) == 0 && len(c.GlobalString("record")) == 0 {
+			// no plucker given: use the config files found, if any
+			conf, files, err := config.Discover()
+			if err != nil {
+				return err
+			}
+			if conf == nil {
 				fmt.Println("Must specify at least one activator. For example -a 'start'.\nSee help and usage with -h")
 				return nil
 			}
-			if len(c.GlobalString("deactivator")) == 0 {
+			if c.GlobalBool("verbose") {
+				for _, f := range files {
+					fmt.Fprintln(os.Stderr, "loaded config file "+f)
+				}
+			}
+			if err = p.LoadConfigs(conf); err != nil {
+				return err
+			}
+		} else {
+			if len(c.GlobalString("deactivator")) == 0 && len(c.GlobalString("record")) == 0 {
 				fmt.Println("Must specify at deactivator. For example -d 'end'.\nSee help and usage with -h")
 				return nil
 			}
-			p.Add(config.Config{
-				Activators:  c.GlobalStringSlice("activator"),
-				Deactivator: c.GlobalString("deactivator"),
-				Limit:       c.GlobalInt("limit"),
-				Sanitize:    c.GlobalBool("sanitize"),
-				Finisher:    c.GlobalString("finisher"),
-				Permanent:   c.GlobalInt("permanent"),
+			err = p.Add(config.Config{
+				Activators:       c.GlobalStringSlice("activator"),
+				Deactivator:      c.GlobalString("deactivator"),
+				Record:           c.GlobalString("record"),
+				Limit:            c.GlobalInt("limit"),
+				Sanitize:         c.GlobalBool("sanitize"),
+				Finisher:         c.GlobalString("finisher"),
+				Permanent:        c.GlobalInt("permanent"),
+				Regex:            c.GlobalBool("regex"),
+				Resolve:          c.GlobalBool("resolve"),
+				Unique:           c.GlobalBool("unique"),
+				UniqueNormalized: c.GlobalBool("unique-normalized"),
+				Type:             c.GlobalString("type"),
+				Format:           c.GlobalString("format"),
 				// add other features later...
 			})
+			if err != nil {
+				return err
+			}
 		}
 
 		if len(c.GlobalString("file")) > 0 {
@@ -148,18 +242,19 @@ $ pluck -a 'Section 2' -a '<a' -a 'href' -a '"' -d '"' -p 1 -finisher "Section 3
 		}
 		var result string
 		if c.GlobalBool("text") {
-			results, ok := p.Result()["0"].([]string)
-			if !ok {
-				results2, ok2 := p.Result()["0"].(string)
-				if !ok2 {
-					fmt.Println("Error?")
-					os.Exit(-1)
-				} else {
-					result = results2
+			r := p.TypedResult()
+			var texts []string
+			for _, name := range r.Names() {
+				captures := r.Get(name)
+				if len(captures) > 0 && captures[0].Children != nil {
+					for _, record := range r.Records(name) {
+						texts = append(texts, recordText(record))
+					}
+					continue
 				}
-			} else {
-				result = strings.Join(results, "\n\n")
+				texts = append(texts, r.Strings(name)...)
 			}
+			result = strings.Join(texts, "\n\n")
 		} else {
 			result = p.ResultJSON(true)
 		}
@@ -172,8 +267,71 @@ $ pluck -a 'Section 2' -a '<a' -a 'href' -a '"' -d '"' -p 1 -finisher "Section 3
 		return nil
 	}
 
+	app.Commands = []cli.Command{benchCommand, configCommand}
+
 	err := app.Run(os.Args)
 	if err != nil {
 		fmt.Print(err)
 	}
 }
+
+// loadConfig loads a config file into the plucker, in the given format or else the detected one.
+func loadConfig(p *pluck.Plucker, f, format string) error {
+	conf, err := config.ParseFile(f, format)
+	if err != nil {
+		return err
+	}
+	return errors.Wrap(p.LoadConfigs(conf), "problem loading config file "+f)
+}
+
+// clientOptions returns the options of the HTTP client set by the flags.
+func clientOptions(c *cli.Context) ([]pluck.Option, error) {
+	var options []pluck.Option
+	for _, header := range c.GlobalStringSlice("header") {
+		i := strings.Index(header, ":")
+		if i < 0 {
+			return nil, fmt.Errorf("header '%s' is not 'Key: Value'", header)
+		}
+		options = append(options, pluck.WithHeader(strings.TrimSpace(header[:i]), strings.TrimSpace(header[i+1:])))
+	}
+	if timeout := c.GlobalDuration("timeout"); timeout > 0 {
+		options = append(options, pluck.WithTimeout(timeout))
+	}
+	if proxy := c.GlobalString("proxy"); proxy != "" {
+		options = append(options, pluck.WithProxy(proxy))
+	}
+	if retries := c.GlobalInt("retries"); retries > 0 {
+		options = append(options, pluck.WithRetry(retries, time.Second))
+	}
+	return options, nil
+}
+
+// recordText formats a record as a "key: value" line per field.
+func recordText(record map[string]interface{}) string {
+	keys := make([]string, 0, len(record))
+	for key := range record {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	lines := make([]string, len(keys))
+	for i, key := range keys {
+		var value string
+		switch v := record[key].(type) {
+		case nil:
+		case string:
+			value = v
+		case []string:
+			value = strings.Join(v, ", ")
+		case []interface{}:
+			values := make([]string, len(v))
+			for j := range v {
+				values[j] = fmt.Sprint(v[j])
+			}
+			value = strings.Join(values, ", ")
+		default:
+			value = fmt.Sprint(v)
+		}
+		lines[i] = strings.TrimSpace(key + ": " + value)
+	}
+	return strings.Join(lines, "\n")
+}
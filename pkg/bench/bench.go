@@ -0,0 +1,148 @@
+// Package bench reads the output of `go test -bench` and compares
+// two runs, so that performance regressions are caught before release.
+package bench
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	// external
+	"github.com/pkg/errors"
+)
+
+// Result is the measure of a benchmark, averaged over its runs.
+type Result struct {
+	Name        string  // name of the benchmark, without the GOMAXPROCS suffix
+	Runs        int     // number of runs (go test -count)
+	NsPerOp     float64 // median time per operation, in nanoseconds
+	BytesPerOp  float64 // median memory allocated per operation
+	AllocsPerOp float64 // median number of allocations per operation
+}
+
+// Delta is the comparison of a benchmark between two runs.
+type Delta struct {
+	Name       string
+	Base       Result
+	Current    Result
+	Change     float64 // relative change of the time per operation, in percent
+	Regression bool    // the change exceeds the threshold
+}
+
+// procsSuffix is the GOMAXPROCS suffix added to benchmark names.
+var procsSuffix = regexp.MustCompile(`-\d+$`)
+
+// Parse reads the output of `go test -bench` and returns the results
+// sorted by name. The runs of a benchmark are summarized by their median.
+func Parse(r io.Reader) ([]Result, error) {
+	runs := make(map[string][][3]float64)
+	var names []string
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 4 || !strings.HasPrefix(fields[0], "Benchmark") {
+			continue
+		}
+		if _, err := strconv.Atoi(fields[1]); err != nil {
+			continue
+		}
+		name := procsSuffix.ReplaceAllString(fields[0], "")
+		var measure [3]float64
+		found := false
+		for i := 2; i+1 < len(fields); i += 2 {
+			value, err := strconv.ParseFloat(fields[i], 64)
+			if err != nil {
+				return nil, errors.Wrapf(err, "line %d", line)
+			}
+			switch fields[i+1] {
+			case "ns/op":
+				measure[0], found = value, true
+			case "B/op":
+				measure[1] = value
+			case "allocs/op":
+				measure[2] = value
+			}
+		}
+		if !found {
+			return nil, errors.Errorf("line %d: no ns/op in benchmark %s", line, name)
+		}
+		if _, ok := runs[name]; !ok {
+			names = append(names, name)
+		}
+		runs[name] = append(runs[name], measure)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	sort.Strings(names)
+	results := make([]Result, len(names))
+	for i, name := range names {
+		results[i] = Result{
+			Name:        name,
+			Runs:        len(runs[name]),
+			NsPerOp:     median(runs[name], 0),
+			BytesPerOp:  median(runs[name], 1),
+			AllocsPerOp: median(runs[name], 2),
+		}
+	}
+	return results, nil
+}
+
+func median(measures [][3]float64, k int) float64 {
+	values := make([]float64, len(measures))
+	for i, m := range measures {
+		values[i] = m[k]
+	}
+	sort.Float64s(values)
+	n := len(values)
+	if n%2 == 1 {
+		return values[n/2]
+	}
+	return (values[n/2-1] + values[n/2]) / 2
+}
+
+// Compare compares the benchmarks found in both runs. A benchmark whose
+// time per operation grew by more than threshold percent is a regression.
+func Compare(base, current []Result, threshold float64) (deltas []Delta) {
+	baseByName := make(map[string]Result)
+	for _, b := range base {
+		baseByName[b.Name] = b
+	}
+	for _, c := range current {
+		b, ok := baseByName[c.Name]
+		if !ok || b.NsPerOp == 0 {
+			continue
+		}
+		change := (c.NsPerOp - b.NsPerOp) / b.NsPerOp * 100
+		deltas = append(deltas, Delta{
+			Name:       c.Name,
+			Base:       b,
+			Current:    c,
+			Change:     change,
+			Regression: change > threshold,
+		})
+	}
+	return
+}
+
+// Report writes a table of the deltas.
+func Report(w io.Writer, deltas []Delta) {
+	width := len("benchmark")
+	for _, d := range deltas {
+		if len(d.Name) > width {
+			width = len(d.Name)
+		}
+	}
+	fmt.Fprintf(w, "%-*s %14s %14s %9s\n", width, "benchmark", "base ns/op", "ns/op", "delta")
+	for _, d := range deltas {
+		mark := ""
+		if d.Regression {
+			mark = "  REGRESSION"
+		}
+		fmt.Fprintf(w, "%-*s %14.0f %14.0f %+8.2f%%%s\n", width, d.Name, d.Base.NsPerOp, d.Current.NsPerOp, d.Change, mark)
+	}
+}
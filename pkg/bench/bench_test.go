@@ -0,0 +1,54 @@
+package bench
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const output = `goos: linux
+goarch: amd64
+pkg: github.com/sniperkit/pluck/pkg/pluck
+BenchmarkParseFile-8         	    2491	    400 ns/op	  164224 B/op	     433 allocs/op
+BenchmarkParseFile-8         	    2521	    600 ns/op	  164224 B/op	     433 allocs/op
+BenchmarkParseFile-8         	    2625	    500 ns/op	  164224 B/op	     433 allocs/op
+BenchmarkParseLarge-8        	       2	 815629132 ns/op	 329.11 MB/s	629025720 B/op	  455720 allocs/op
+BenchmarkPluck/stream/test.txt-8	  100	  1000 ns/op
+PASS
+ok  	github.com/sniperkit/pluck/pkg/pluck	20.521s
+`
+
+func TestParse(t *testing.T) {
+	results, err := Parse(strings.NewReader(output))
+	assert.Nil(t, err)
+	assert.Equal(t, []Result{
+		{Name: "BenchmarkParseFile", Runs: 3, NsPerOp: 500, BytesPerOp: 164224, AllocsPerOp: 433},
+		{Name: "BenchmarkParseLarge", Runs: 1, NsPerOp: 815629132, BytesPerOp: 629025720, AllocsPerOp: 455720},
+		{Name: "BenchmarkPluck/stream/test.txt", Runs: 1, NsPerOp: 1000},
+	}, results)
+
+	_, err = Parse(strings.NewReader("BenchmarkParseFile-8 10 fast ns/op\n"))
+	assert.NotNil(t, err)
+}
+
+func TestCompare(t *testing.T) {
+	base := []Result{{Name: "A", NsPerOp: 100}, {Name: "B", NsPerOp: 100}, {Name: "C", NsPerOp: 100}}
+	current := []Result{{Name: "A", NsPerOp: 105}, {Name: "B", NsPerOp: 150}, {Name: "D", NsPerOp: 100}}
+	deltas := Compare(base, current, 10)
+	if assert.Equal(t, 2, len(deltas)) {
+		assert.Equal(t, "A", deltas[0].Name)
+		assert.InDelta(t, 5, deltas[0].Change, 1e-9)
+		assert.False(t, deltas[0].Regression)
+		assert.Equal(t, "B", deltas[1].Name)
+		assert.True(t, deltas[1].Regression)
+	}
+
+	var b bytes.Buffer
+	Report(&b, deltas)
+	assert.Equal(t, `benchmark     base ns/op          ns/op     delta
+A                    100            105    +5.00%
+B                    100            150   +50.00%  REGRESSION
+`, b.String())
+}
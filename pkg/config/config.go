@@ -7,26 +7,32 @@ type Configs struct {
 	Debug bool `default:"false" json:"debug,omitempty" yaml:"debug,omitempty" toml:"debug,omitempty" xml:"debug,omitempty" ini:"debug,omitempty"`
 
 	// Verbose
-	Verbose bool `default:"true" json:"verbose,omitempty" yaml:"verbose,omitempty" toml:"verbose,omitempty" xml:"verbose,omitempty" ini:"verbose,omitempty"`
+	Verbose bool `default:"true" json:"verbose" yaml:"verbose" toml:"verbose" xml:"verbose" ini:"verbose"`
 
 	// XDGBaseDir specifies
 	XDGBaseDir string `json:"xdg_base_dir,omitempty" yaml:"xdg_base_dir,omitempty" toml:"xdg_base_dir,omitempty" xml:"xdgBaseDir,omitempty" ini:"xdgBaseDir,omitempty"`
 
 	// Pluck specifies the list of content plucking units
-	Pluck []Config `json:"plucker" yaml:"plucker" toml:"plucker" xml:"plucker" ini:"plucker"`
+	Pluck []Config `json:"pluck" yaml:"pluck" toml:"pluck" xml:"pluck" ini:"pluck"`
+
+	// Plucker is the former key of Pluck, deprecated: Parse moves its pluckers to Pluck
+	Plucker []Config `json:"plucker,omitempty" yaml:"plucker,omitempty" toml:"plucker,omitempty" xml:"plucker,omitempty" ini:"plucker,omitempty"`
+
+	// fields given by the configuration file it was loaded from, nil if not loaded from a file (see Merge)
+	given map[string]bool
 }
 
 // Config specifies parameters for plucking
 type Config struct {
 
-	// Enabled
-	Enabled bool `default:"true" json:"enabled,omitempty" yaml:"enabled,omitempty" toml:"enabled,omitempty" xml:"enabled,omitempty" ini:"enabled,omitempty"`
+	// Enabled unless set to false, the loaders skipping a disabled plucker (Plucker.Add ignores it), see IsEnabled
+	Enabled *bool `default:"true" json:"enabled,omitempty" yaml:"enabled,omitempty" toml:"enabled,omitempty" xml:"enabled,omitempty" ini:"enabled,omitempty"`
 
 	// Debug
 	Debug bool `default:"false" json:"debug,omitempty" yaml:"debug,omitempty" toml:"debug,omitempty" xml:"debug,omitempty" ini:"debug,omitempty"`
 
 	// Verbose
-	Verbose bool `default:"true" json:"verbose,omitempty" yaml:"verbose,omitempty" toml:"verbose,omitempty" xml:"verbose,omitempty" ini:"verbose,omitempty"`
+	Verbose bool `default:"true" json:"verbose" yaml:"verbose" toml:"verbose" xml:"verbose" ini:"verbose"`
 
 	// Sanitize html content
 	Sanitize bool `default:"false" json:"sanitize,omitempty" yaml:"sanitize,omitempty" toml:"sanitize,omitempty" xml:"sanitize,omitempty" ini:"sanitize,omitempty"`
@@ -35,7 +41,7 @@ type Config struct {
 	Name string `required:"true" json:"name" yaml:"name" toml:"name" xml:"name" ini:"name"`
 
 	// must be found in order, before capturing commences
-	Activators []string `json:"activators" yaml:"activators" toml:"activators" xml:"activators" ini:"activators"`
+	Activators []string `json:"activators,omitempty" yaml:"activators,omitempty" toml:"activators,omitempty" xml:"activators,omitempty" ini:"activators,omitempty"`
 
 	// number of activators that stay permanently (counted from left to right)
 	Permanent int `json:"permanent" yaml:"permanent" toml:"permanent" xml:"permanent" ini:"permanent"`
@@ -46,12 +52,22 @@ type Config struct {
 	// finishes capturing this pluck
 	Finisher string `json:"finisher,omitempty" yaml:"finisher,omitempty" toml:"finisher,omitempty" xml:"finisher,omitempty" ini:"finisher,omitempty"`
 
+	// activators, deactivator and finisher are regular expressions matching at most 4096 bytes
+	// (a single one can be prefixed with "re:")
+	Regex bool `json:"regex,omitempty" yaml:"regex,omitempty" toml:"regex,omitempty" xml:"regex,omitempty" ini:"regex,omitempty"`
+
 	// specifies the number of times capturing can occur
 	Limit int `default:"-1" json:"limit" yaml:"limit" toml:"limit" xml:"limit" ini:"limit"`
 
 	// maximum number of characters for a capture
 	Maximum int `json:"maximum,omitempty" yaml:"maximum,omitempty" toml:"maximum,omitempty" xml:"maximum,omitempty" ini:"maximum,omitempty"`
 
+	// maximum number of bytes buffered for a capture (no limit if not set)
+	CaptureLimit int `json:"capture_limit,omitempty" yaml:"capture_limit,omitempty" toml:"capture_limit,omitempty" xml:"captureLimit,omitempty" ini:"captureLimit,omitempty"`
+
+	// what to do with a capture longer than the capture limit: truncate (default), skip or error
+	CaptureOverflow string `default:"truncate" json:"capture_overflow,omitempty" yaml:"capture_overflow,omitempty" toml:"capture_overflow,omitempty" xml:"captureOverflow,omitempty" ini:"captureOverflow,omitempty"`
+
 	// Match specifies...
 	Match Match `json:"match" yaml:"match" toml:"match" xml:"match" ini:"match"`
 
@@ -70,13 +86,40 @@ type Config struct {
 	*/
 
 	// identify some optional patterns to split down results
-	Patterns []string `json:"patterns" yaml:"patterns" toml:"patterns" xml:"patterns" ini:"patterns"`
+	Patterns []string `json:"patterns,omitempty" yaml:"patterns,omitempty" toml:"patterns,omitempty" xml:"patterns,omitempty" ini:"patterns,omitempty"`
 
 	// set a word list to include a plucked occurrence
-	Whitelist []string `json:"whitelist" yaml:"whitelist" toml:"whitelist" xml:"whitelist" ini:"whitelist"`
+	Whitelist []string `json:"whitelist,omitempty" yaml:"whitelist,omitempty" toml:"whitelist,omitempty" xml:"whitelist,omitempty" ini:"whitelist,omitempty"`
 
 	// set a word list to exclude a plucked occurrence
-	Blacklist []string `json:"activatoblacklistrs" yaml:"blacklist" toml:"blacklist" xml:"blacklist" ini:"blacklist"`
+	Blacklist []string `json:"blacklist,omitempty" yaml:"blacklist,omitempty" toml:"blacklist,omitempty" xml:"blacklist,omitempty" ini:"blacklist,omitempty"`
+
+	// keeps only the first of the plucked occurences with the same text, the limit then counting distinct occurences
+	Unique bool `json:"unique,omitempty" yaml:"unique,omitempty" toml:"unique,omitempty" xml:"unique,omitempty" ini:"unique,omitempty"`
+
+	// like unique, the texts being compared with their white space collapsed and in lower case
+	UniqueNormalized bool `json:"unique_normalized,omitempty" yaml:"unique_normalized,omitempty" toml:"unique_normalized,omitempty" xml:"uniqueNormalized,omitempty" ini:"uniqueNormalized,omitempty"`
+
+	// pluckers applied to each capture, which then gives an object with a key per child
+	Children []Config `json:"children,omitempty" yaml:"children,omitempty" toml:"children,omitempty" xml:"children,omitempty" ini:"children,omitempty"`
+
+	// starts each record, replacing the activators and the deactivator: a record ends at the next record start, the finisher or the end of the input
+	Record string `json:"record,omitempty" yaml:"record,omitempty" toml:"record,omitempty" xml:"record,omitempty" ini:"record,omitempty"`
+
+	// transforms applied in order to each plucked occurence
+	Transforms []Transform `json:"transforms,omitempty" yaml:"transforms,omitempty" toml:"transforms,omitempty" xml:"transforms,omitempty" ini:"transforms,omitempty"`
+
+	// resolves the plucked occurences as URLs against the URL of the page, or its <base href>, after the transforms
+	Resolve bool `json:"resolve,omitempty" yaml:"resolve,omitempty" toml:"resolve,omitempty" xml:"resolve,omitempty" ini:"resolve,omitempty"`
+
+	// type the plucked occurences are converted to, after the transforms: string (default), int, float, bool, date or url
+	Type string `default:"string" json:"type,omitempty" yaml:"type,omitempty" toml:"type,omitempty" xml:"type,omitempty" ini:"type,omitempty"`
+
+	// layout of a date, as the reference time "2006-01-02T15:04:05Z07:00" would be written, or decimal separator of a number, "." or ","
+	Format string `json:"format,omitempty" yaml:"format,omitempty" toml:"format,omitempty" xml:"format,omitempty" ini:"format,omitempty"`
+
+	// line of the plucker in the configuration file it was loaded from (see Line)
+	line int
 
 	//-- End
 }
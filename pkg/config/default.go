@@ -21,11 +21,11 @@ var (
 	// DefaultConfigFormatList
 	DefaultConfigFormatList []string = []string{"yaml", "yml", "toml", "ini", "xml", "json"}
 
-	// DefaultConfigPrefixPathList
+	// DefaultConfigPrefixPathList names the directories searched for config files (see ConfigDirs)
 	DefaultConfigPrefixPathList []string = []string{"conf", "plucker", "pluck", ".plucker", ".pluck"}
 
-	// DefaultConfigFilepaths
-	DefaultConfigFilepaths []string = []string{"conf/config.toml", "~/.plucker/config", "~/.plucker/config.yaml"}
+	// DefaultConfigFilepaths lists the config files Discover looks for, as set by GenerateExpectedFilepaths
+	DefaultConfigFilepaths []string
 
 	//-- Ends
 )
@@ -37,30 +37,20 @@ func init() {
 		fmt.Println("error while trying to get the default xdgb base directory")
 		os.Exit(1)
 	}
+	GenerateExpectedFilepaths("")
 }
 
-// GenerateExpectedFilepaths
+// GenerateExpectedFilepaths sets DefaultConfigFilepaths to the files Discover looks for, from the directories
+// of ConfigDirs, those of the project being in pp (the current directory if empty)
 func GenerateExpectedFilepaths(pp string) {
-	var defaultConfigPrefixPathList []string
-
-	defaultConfigPrefixPathList = append(defaultConfigPrefixPathList, DefaultConfigPrefixPathList...)
-	for _, p := range DefaultConfigPrefixPathList {
-		newPath := fmt.Sprintf("%s/%s", DefaultXDGBaseDirectory, p)
-		for _, b := range DefaultConfigBasenameList {
-			newPath := fmt.Sprintf("%s/%s", newPath, b)
-			for _, f := range DefaultConfigFormatList {
-				newPath := fmt.Sprintf("%s.%s", newPath, f)
-				defaultConfigPrefixPathList = append(defaultConfigPrefixPathList, newPath)
-			}
-		}
-	}
-	if len(defaultConfigPrefixPathList) > 0 {
-		DefaultConfigFilepaths = defaultConfigPrefixPathList
+	var files []string
+	for _, dir := range configDirs(pp) {
+		files = append(files, configFiles(dir)...)
 	}
-	// pp.Println(defaultConfigPrefixPathList)
+	DefaultConfigFilepaths = files
 }
 
-// AddConfigPaths
+// AddConfigPaths adds directories to DefaultConfigPrefixPathList, and so to ConfigDirs
 func AddConfigPaths(pp ...string) {
 	for _, p := range pp {
 		DefaultConfigPrefixPathList = append(DefaultConfigPrefixPathList, p)
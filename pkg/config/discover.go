@@ -0,0 +1,124 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ConfigDirs returns the directories searched by Discover, from the most global to the most local, named
+// after DefaultConfigPrefixPathList: each prefix in the XDG config directory, then the hidden ones (such as
+// .plucker) in the home directory and the others (such as conf) in the current directory
+func ConfigDirs() []string {
+	return configDirs("")
+}
+
+// configDirs returns the directories of ConfigDirs, those of the project being in base
+func configDirs(base string) []string {
+	var dirs []string
+	if DefaultXDGBaseDirectory != "" && DefaultXDGBaseDirectory != DEFAULT_BASE_DIR {
+		for _, prefix := range DefaultConfigPrefixPathList {
+			dirs = append(dirs, filepath.Join(DefaultXDGBaseDirectory, prefix))
+		}
+	}
+	if home := os.Getenv("HOME"); home != "" {
+		for _, prefix := range DefaultConfigPrefixPathList {
+			if strings.HasPrefix(prefix, ".") {
+				dirs = append(dirs, filepath.Join(home, prefix))
+			}
+		}
+	}
+	for _, prefix := range DefaultConfigPrefixPathList {
+		if !strings.HasPrefix(prefix, ".") {
+			dirs = append(dirs, filepath.Join(base, prefix))
+		}
+	}
+	return dirs
+}
+
+// Discover loads the configuration files found in the given directories, or in ConfigDirs if none, from the most
+// global to the most local, each one being merged into the previous ones (see Merge). In each directory, the first
+// file named after DefaultConfigBasenameList, with an extension of DefaultConfigFormatList or none, is loaded.
+// It returns the merged configuration and the files loaded, or a nil configuration if none is found.
+func Discover(dirs ...string) (*Configs, []string, error) {
+	if len(dirs) == 0 {
+		dirs = ConfigDirs()
+	}
+	var conf *Configs
+	var files []string
+	for _, dir := range dirs {
+		f := findConfig(dir)
+		if f == "" {
+			continue
+		}
+		c, err := ParseFile(f, "")
+		if err != nil {
+			return nil, files, err
+		}
+		if conf == nil {
+			conf = c
+		} else {
+			conf.Merge(c)
+		}
+		files = append(files, f)
+	}
+	return conf, files, nil
+}
+
+// gives reports whether the configuration sets the given field, always true if it was not loaded from a file
+func (c *Configs) gives(field string) bool {
+	return c.given == nil || c.given[field]
+}
+
+// findConfig returns the first configuration file of a directory, or "" if none
+func findConfig(dir string) string {
+	for _, f := range configFiles(dir) {
+		if info, err := os.Stat(f); err == nil && info.Mode().IsRegular() {
+			return f
+		}
+	}
+	return ""
+}
+
+// configFiles returns the configuration files looked for in a directory, in order
+func configFiles(dir string) (files []string) {
+	exts := append([]string{}, DefaultConfigFormatList...)
+	for _, basename := range DefaultConfigBasenameList {
+		for _, ext := range append(exts, "") {
+			f := filepath.Join(dir, basename)
+			if ext != "" {
+				f += "." + ext
+			}
+			files = append(files, f)
+		}
+	}
+	return
+}
+
+// Merge merges a more local configuration into c: its pluckers replace the ones of c with the same name,
+// the others being added after them, and the settings it gives replace the ones of c (all of them if it was
+// not loaded from a file)
+func (c *Configs) Merge(local *Configs) {
+	if local.gives("Debug") {
+		c.Debug = local.Debug
+	}
+	if local.gives("Verbose") {
+		c.Verbose = local.Verbose
+	}
+	if local.XDGBaseDir != "" {
+		c.XDGBaseDir = local.XDGBaseDir
+	}
+	index := make(map[string]int)
+	for i, p := range c.Pluck {
+		if p.Name != "" {
+			index[p.Name] = i
+		}
+	}
+	for _, p := range local.Pluck {
+		if i, ok := index[p.Name]; ok && p.Name != "" {
+			c.Pluck[i] = p
+			continue
+		}
+		c.Pluck = append(c.Pluck, p)
+	}
+}
@@ -0,0 +1,100 @@
+package config
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDiscover(t *testing.T) {
+	root, err := ioutil.TempDir("", "pluck")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(root)
+	global, project, empty := filepath.Join(root, "global"), filepath.Join(root, "project"), filepath.Join(root, "empty")
+	for _, dir := range []string{global, project, empty} {
+		os.Mkdir(dir, 0755)
+	}
+	ioutil.WriteFile(filepath.Join(global, "config"), []byte(`
+debug = true
+
+[[pluck]]
+name = "title"
+activators = ["<title>"]
+deactivator = "<"
+
+[[pluck]]
+name = "links"
+activators = ['href="']
+deactivator = '"'
+`), 0644)
+	// the first basename is loaded
+	ioutil.WriteFile(filepath.Join(project, "pluck.yaml"), []byte(`
+pluck:
+- name: links
+  activators: ['<a href="']
+  deactivator: '"'
+  resolve: true
+- name: headings
+  activators: [<h1>]
+  deactivator: </h1>
+`), 0644)
+	ioutil.WriteFile(filepath.Join(project, "plucker.json"), []byte(`{`), 0644)
+
+	conf, files, err := Discover(global, empty, filepath.Join(root, "missing"), project)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(global, "config"), filepath.Join(project, "pluck.yaml")}, files)
+	if assert.NotNil(t, conf) && assert.Equal(t, 3, len(conf.Pluck)) {
+		assert.Equal(t, "title", conf.Pluck[0].Name)
+		assert.Equal(t, []string{`<a href="`}, conf.Pluck[1].Activators)
+		assert.True(t, conf.Pluck[1].Resolve)
+		assert.Equal(t, "headings", conf.Pluck[2].Name)
+	}
+	// the settings not given by the most local file are kept
+	assert.True(t, conf.Debug)
+	assert.True(t, conf.Verbose)
+
+	// the ones it gives win
+	ioutil.WriteFile(filepath.Join(empty, "pluck.json"), []byte(`{"debug": false, "pluck": []}`), 0644)
+	conf, _, err = Discover(global, empty)
+	assert.Nil(t, err)
+	assert.False(t, conf.Debug)
+	os.Remove(filepath.Join(empty, "pluck.json"))
+
+	conf, files, err = Discover(empty)
+	assert.Nil(t, err)
+	assert.Nil(t, conf)
+	assert.Nil(t, files)
+
+	ioutil.WriteFile(filepath.Join(empty, "config.toml"), []byte("[[pluck]]\nname = \"a\"\n"), 0644)
+	_, files, err = Discover(global, empty)
+	assert.EqualError(t, err, "problem loading config file "+filepath.Join(empty, "config.toml")+": line 1: plucker a: missing deactivator")
+	assert.Equal(t, []string{filepath.Join(global, "config")}, files)
+}
+
+func TestConfigDirs(t *testing.T) {
+	dirs := ConfigDirs()
+	// the project directories are the most local
+	assert.Equal(t, []string{"conf", "plucker", "pluck"}, dirs[len(dirs)-3:])
+	if home := os.Getenv("HOME"); home != "" {
+		assert.Contains(t, dirs, filepath.Join(home, ".plucker"))
+		assert.NotContains(t, dirs, filepath.Join(home, "conf"))
+	}
+	if DefaultXDGBaseDirectory != "" && DefaultXDGBaseDirectory != DEFAULT_BASE_DIR {
+		assert.Equal(t, filepath.Join(DefaultXDGBaseDirectory, "conf"), dirs[0])
+	}
+
+	// the expected files are the ones of these directories
+	GenerateExpectedFilepaths("project")
+	defer GenerateExpectedFilepaths("")
+	assert.Contains(t, DefaultConfigFilepaths, filepath.Join("project", "conf", "config.toml"))
+	assert.Contains(t, DefaultConfigFilepaths, filepath.Join("project", "pluck", "plucker"))
+	if home := os.Getenv("HOME"); home != "" {
+		assert.Contains(t, DefaultConfigFilepaths, filepath.Join(home, ".plucker", "config.yaml"))
+	}
+	assert.Equal(t, len(DefaultConfigFilepaths), len(ConfigDirs())*len(DefaultConfigBasenameList)*(len(DefaultConfigFormatList)+1))
+}
@@ -0,0 +1,43 @@
+package config
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"io"
+
+	// external
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Export writes the configuration in the given format (toml, yaml, json, xml or ini),
+// which Parse reads back to the same configuration
+func (c *Configs) Export(w io.Writer, format string) error {
+	var err error
+	switch normalizeFormat(format) {
+	case "toml":
+		err = toml.NewEncoder(w).Encode(c)
+	case "yaml":
+		var data []byte
+		if data, err = yaml.Marshal(c); err == nil {
+			_, err = w.Write(data)
+		}
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "    ")
+		enc.SetEscapeHTML(false)
+		err = enc.Encode(c)
+	case "xml":
+		enc := xml.NewEncoder(w)
+		enc.Indent("", "    ")
+		if err = enc.EncodeElement(c, xml.StartElement{Name: xml.Name{Local: "configs"}}); err == nil {
+			_, err = io.WriteString(w, "\n")
+		}
+	case "ini":
+		err = iniWrite(w, c)
+	default:
+		return errors.Errorf("unknown configuration format '%s'", format)
+	}
+	return errors.Wrap(err, "problem exporting configuration")
+}
@@ -0,0 +1,78 @@
+package config
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExport(t *testing.T) {
+	conf, err := Parse([]byte(`
+debug = true
+verbose = false
+
+[[pluck]]
+name = "songs"
+activators = ["<ul", ">"]
+deactivator = "</ul>"
+enabled = false
+whitelist = ["love", ' "quoted" ']
+
+[pluck.match]
+mode = "all"
+split = false
+
+[[pluck.children]]
+name = "title"
+activators = ["<li>"]
+deactivator = " </li>"
+limit = 2
+type = "date"
+format = "2006-01-02"
+
+[[pluck.children.transforms]]
+name = "regex_replace"
+args = ['\s+', " "]
+
+[[pluck.children]]
+name = "href"
+activators = ['href="']
+deactivator = '"'
+resolve = true
+
+[[pluck.transforms]]
+name = "trim"
+
+[[pluck]]
+record = "<tr>"
+`), "toml")
+	if !assert.Nil(t, err) {
+		return
+	}
+	clearLines(conf.Pluck)
+
+	for _, format := range []string{"toml", "yaml", "json", "xml", "ini"} {
+		var b bytes.Buffer
+		if !assert.Nil(t, conf.Export(&b, format), format) {
+			continue
+		}
+		// the export is read back to the same configuration
+		exported, err := Parse(b.Bytes(), format)
+		if assert.Nil(t, err, "%s:\n%s", format, b.String()) {
+			clearLines(exported.Pluck)
+			assert.Equal(t, conf, exported, "%s:\n%s", format, b.String())
+			assert.Equal(t, format, DetectFormat("", b.Bytes()), format)
+		}
+	}
+
+	assert.EqualError(t, conf.Export(&bytes.Buffer{}, "csv"), "unknown configuration format 'csv'")
+}
+
+// clearLines forgets the lines of pluckers, only known in some formats
+func clearLines(configs []Config) {
+	for i := range configs {
+		configs[i].line = 0
+		clearLines(configs[i].Children)
+	}
+}
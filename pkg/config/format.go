@@ -0,0 +1,64 @@
+package config
+
+import (
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+var (
+	// yamlKey matches a line starting a YAML mapping
+	yamlKey = regexp.MustCompile(`^[A-Za-z_][\w-]*\s*:(\s|$)`)
+
+	// assignment matches a key given a value, in TOML or INI
+	assignment = regexp.MustCompile(`^[A-Za-z_][\w.-]*\s*=\s*(.*)$`)
+)
+
+// DetectFormat returns the format of a configuration from the extension of its file name, if known,
+// or else from its content: json, xml, yaml, ini or, by default, toml
+func DetectFormat(name string, data []byte) string {
+	switch ext := strings.ToLower(strings.TrimPrefix(filepath.Ext(name), ".")); ext {
+	case "yml":
+		return "yaml"
+	case "yaml", "json", "toml", "xml", "ini":
+		return ext
+	}
+
+	first := true
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if first {
+			first = false
+			switch {
+			case line[0] == '{':
+				return "json"
+			case line[0] == '<':
+				return "xml"
+			case line == "---" || strings.HasPrefix(line, "- ") || yamlKey.MatchString(line):
+				return "yaml"
+			}
+		}
+		if strings.HasPrefix(line, "[[") {
+			return "toml"
+		}
+		// only TOML has arrays, and it quotes all its strings
+		if m := assignment.FindStringSubmatch(line); m != nil {
+			switch value := m[1]; {
+			case value == "":
+				return "ini"
+			case strings.ContainsAny(value[:1], "[{"):
+				return "toml"
+			case strings.ContainsAny(value[:1], `"'`) || value == "true" || value == "false":
+			default:
+				if _, err := strconv.ParseFloat(value, 64); err != nil {
+					return "ini"
+				}
+			}
+		}
+	}
+	return "toml"
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDetectFormat(t *testing.T) {
+	for _, test := range []struct {
+		name   string
+		data   string
+		format string
+	}{
+		{"config.yml", "", "yaml"},
+		{"CONFIG.YAML", "", "yaml"},
+		{"conf/pluck.json", "", "json"},
+		{"a.ini", "[[pluck]]", "ini"},
+		{"a.xml", "", "xml"},
+		{"a.toml", "", "toml"},
+		{"config", "", "toml"},
+		{"", "\n  {\"pluck\": []}", "json"},
+		{"", "<?xml version=\"1.0\"?><configs/>", "xml"},
+		{"", "# pluckers\npluck:\n- name: a", "yaml"},
+		{"", "---\npluck: []", "yaml"},
+		{"", "- name: a", "yaml"},
+		{"", "[[pluck]]\nname = \"a\"", "toml"},
+		{"", "[pluck]\nlimit = 3\nname = \"a\"\nactivators = [\"<b>\"]", "toml"},
+		{"", "[pluck]\nlimit = 3\nname = \"a\"\nactivators = <b>", "ini"},
+		{"", "; pluckers\n[pluck]\nname =\n", "ini"},
+		{"", "", "toml"},
+	} {
+		assert.Equal(t, test.format, DetectFormat(test.name, []byte(test.data)), "%s %q", test.name, test.data)
+	}
+}
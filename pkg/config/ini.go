@@ -0,0 +1,165 @@
+package config
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"reflect"
+	"strconv"
+	"strings"
+
+	// external
+	"github.com/pkg/errors"
+)
+
+// iniRaw decodes an INI configuration in a generic way, indexing its lines. INI having no lists, the fields of
+// Configs guide the decoding: a section naming a list of tables, such as [pluck] or [pluck.children], adds a table
+// to the list (in the last table of its parent), and a key of a list, such as activators, is given once per element.
+// Values can be quoted to keep their surrounding spaces.
+func iniRaw(data string) (map[string]interface{}, *lineIndex, error) {
+	li := &lineIndex{tables: make(map[string]int), keys: make(map[string]int)}
+	root := make(map[string]interface{})
+	table, typ, path := root, reflect.TypeOf(Configs{}), ""
+	for i, line := range strings.Split(data, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "" || line[0] == ';' || line[0] == '#':
+		case line[0] == '[':
+			if !strings.HasSuffix(line, "]") {
+				return nil, nil, errors.Errorf("line %d: invalid section %s", i+1, line)
+			}
+			table, typ, path = iniSection(root, strings.TrimSpace(line[1:len(line)-1]))
+			li.tables[path] = i
+		default:
+			j := strings.Index(line, "=")
+			if j <= 0 {
+				return nil, nil, errors.Errorf("line %d: expected key = value, got %s", i+1, line)
+			}
+			key, value := strings.TrimSpace(line[:j]), unquote(strings.TrimSpace(line[j+1:]))
+			if _, ok := li.keys[path+key]; !ok {
+				li.keys[path+key] = i
+			}
+			if field, ok := iniField(typ, key); ok && field.Type.Kind() == reflect.Slice {
+				list, _ := table[key].([]interface{})
+				table[key] = append(list, value)
+			} else {
+				table[key] = value
+			}
+		}
+	}
+	return root, li, nil
+}
+
+// iniSection returns the table of a section, with the type of its struct (nil if unknown) and its path
+func iniSection(root map[string]interface{}, name string) (map[string]interface{}, reflect.Type, string) {
+	table, typ, path := root, reflect.TypeOf(Configs{}), ""
+	parts := strings.Split(name, ".")
+	for n, part := range parts {
+		field, ok := iniField(typ, part)
+		if ok && field.Type.Kind() == reflect.Slice && field.Type.Elem().Kind() == reflect.Struct {
+			list, _ := table[part].([]interface{})
+			if n == len(parts)-1 || len(list) == 0 {
+				list = append(list, make(map[string]interface{}))
+				table[part] = list
+			}
+			table, typ = list[len(list)-1].(map[string]interface{}), field.Type.Elem()
+			path += part + "." + strconv.Itoa(len(list)-1) + "."
+			continue
+		}
+		next, isMap := table[part].(map[string]interface{})
+		if !isMap {
+			next = make(map[string]interface{})
+			table[part] = next
+		}
+		table, typ = next, nil
+		if ok && field.Type.Kind() == reflect.Struct {
+			typ = field.Type
+		}
+		path += part + "."
+	}
+	return table, typ, path
+}
+
+// iniField returns the field of a struct type with the given ini key
+func iniField(typ reflect.Type, key string) (reflect.StructField, bool) {
+	if typ == nil {
+		return reflect.StructField{}, false
+	}
+	for i := 0; i < typ.NumField(); i++ {
+		if strings.Split(typ.Field(i).Tag.Get("ini"), ",")[0] == key {
+			return typ.Field(i), true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+// unquote removes the quotes around a value, if any
+func unquote(value string) string {
+	if len(value) >= 2 && (value[0] == '"' || value[0] == '\'') && value[len(value)-1] == value[0] {
+		return value[1 : len(value)-1]
+	}
+	return value
+}
+
+// iniWrite writes a configuration in INI, as iniRaw reads it
+func iniWrite(w io.Writer, c *Configs) error {
+	b := &bytes.Buffer{}
+	iniTable(b, reflect.ValueOf(c).Elem(), "")
+	_, err := b.WriteTo(w)
+	return err
+}
+
+// iniTable writes the keys of a struct, then its tables as sections named after their path
+func iniTable(b *bytes.Buffer, v reflect.Value, path string) {
+	t := v.Type()
+	var tables []int
+	for i := 0; i < t.NumField(); i++ {
+		f, tag := v.Field(i), strings.Split(t.Field(i).Tag.Get("ini"), ",")
+		if t.Field(i).PkgPath != "" || tag[0] == "" || tag[0] == "-" {
+			continue
+		}
+		switch {
+		case f.Kind() == reflect.Ptr && f.IsNil():
+		case f.Kind() == reflect.Ptr:
+			iniKey(b, tag[0], f.Elem())
+		case f.Kind() == reflect.Struct || f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Struct:
+			tables = append(tables, i)
+		case f.Kind() == reflect.Slice:
+			for j := 0; j < f.Len(); j++ {
+				iniKey(b, tag[0], f.Index(j))
+			}
+		case len(tag) > 1 && tag[1] == "omitempty" && reflect.DeepEqual(f.Interface(), reflect.Zero(f.Type()).Interface()):
+		default:
+			iniKey(b, tag[0], f)
+		}
+	}
+	for _, i := range tables {
+		f, key := v.Field(i), path+strings.Split(t.Field(i).Tag.Get("ini"), ",")[0]
+		if f.Kind() == reflect.Struct {
+			fmt.Fprintf(b, "\n[%s]\n", key)
+			iniTable(b, f, key+".")
+			continue
+		}
+		for j := 0; j < f.Len(); j++ {
+			fmt.Fprintf(b, "\n[%s]\n", key)
+			iniTable(b, f.Index(j), key+".")
+		}
+	}
+}
+
+// iniKey writes a key with its value
+func iniKey(b *bytes.Buffer, key string, v reflect.Value) {
+	if value := quote(fmt.Sprint(v.Interface())); value != "" {
+		fmt.Fprintf(b, "%s = %s\n", key, value)
+	} else {
+		fmt.Fprintf(b, "%s =\n", key)
+	}
+}
+
+// quote quotes a value which unquote would change otherwise
+func quote(value string) string {
+	if value != strings.TrimSpace(value) || unquote(value) != value {
+		return `"` + value + `"`
+	}
+	return value
+}
@@ -45,7 +45,9 @@ func NewFromFile(verbose, debug, esrrorOnUnmatchedKeys bool, files ...string) (*
 		return nil, err
 	}
 	globalConfig.XDGBaseDir = xdgPath
-	configor.New(&configor.Config{Debug: debug, Verbose: verbose, ErrorOnUnmatchedKeys: false}).Load(&globalConfig, files...)
+	if err := configor.New(&configor.Config{Debug: debug, Verbose: verbose, ErrorOnUnmatchedKeys: false}).Load(&globalConfig, files...); err != nil {
+		return nil, err
+	}
 
 	return globalConfig, nil
 }
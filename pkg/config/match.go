@@ -1,7 +1,7 @@
 package config
 
 /*
-	Boolean queries allow the following special operators to be used:
+	Boolean queries (see the query package) allow the following special operators to be used:
 
 	- Operator MAYBE:
 	   - `hello MAYBE world`
@@ -37,7 +37,7 @@ type Match struct {
 	// Split plucked occurences with a user-defined separator
 	Split bool `default:"true" json:"split" yaml:"split" toml:"split" xml:"split" ini:"split"`
 
-	// separator inside the match to use if we want to join all the occurences into a slice of strings
+	// phrase required by the phrase mode, or boolean query used by the boolean mode
 	Phrase string `json:"phrase" yaml:"phrase" toml:"phrase" xml:"phrase" ini:"phrase"`
 
 	//-- End
@@ -0,0 +1,11 @@
+package config
+
+// OverflowMode specifies what happens to a plucked occurence longer than the capture limit
+type OverflowMode string
+
+// Enum list of all available overflow modes
+const (
+	OVERFLOW_TRUNCATE OverflowMode = "truncate" // keeps the beginning of the plucked occurence, up to the capture limit
+	OVERFLOW_SKIP     OverflowMode = "skip"     // drops the plucked occurence
+	OVERFLOW_ERROR    OverflowMode = "error"    // drops the plucked occurence, stops the plucker and reports an error
+)
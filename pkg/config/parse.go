@@ -0,0 +1,342 @@
+package config
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	// external
+	"github.com/BurntSushi/toml"
+	"github.com/pkg/errors"
+	yaml "gopkg.in/yaml.v2"
+)
+
+// Parse decodes a configuration in the given format (toml, yaml, json, xml or ini), sets the fields it does not give
+// to their default and checks it, returning all the problems found as Errors, located by line when possible (toml and ini)
+func Parse(data []byte, format string) (*Configs, error) {
+	format = normalizeFormat(format)
+	raw, lines, err := decodeRaw(data, format)
+	if err != nil {
+		return nil, err
+	}
+	conf := &Configs{}
+	switch format {
+	case "toml":
+		_, err = toml.Decode(string(data), conf)
+	case "yaml":
+		err = yaml.Unmarshal(data, conf)
+	case "json":
+		err = json.Unmarshal(data, conf)
+	case "xml":
+		err = xml.Unmarshal(data, conf)
+	}
+	if err != nil {
+		return nil, errors.Wrap(err, "problem parsing configuration")
+	}
+
+	// ini has no typed decoding: the fields are filled from the keys
+	var errs Errors
+	d := &decoder{tag: format, lines: lines, errs: &errs, fill: format == "ini"}
+	d.walk(reflect.ValueOf(conf).Elem(), raw, "")
+	conf.Pluck, conf.Plucker = append(conf.Pluck, conf.Plucker...), nil
+	if err := conf.Validate(); err != nil {
+		errs = append(errs, err.(Errors)...)
+	}
+	sort.SliceStable(errs, func(i, j int) bool { return errorLine(errs[i]) < errorLine(errs[j]) })
+	if len(errs) > 0 {
+		return conf, errs
+	}
+	return conf, nil
+}
+
+// normalizeFormat returns the name of a format as used by the struct tags
+func normalizeFormat(format string) string {
+	if format = strings.ToLower(format); format == "yml" {
+		return "yaml"
+	}
+	return format
+}
+
+// decodeRaw decodes a configuration in a generic way, indexing its lines if possible
+func decodeRaw(data []byte, format string) (raw map[string]interface{}, lines *lineIndex, err error) {
+	switch format {
+	case "toml":
+		_, err = toml.Decode(string(data), &raw)
+		lines = tomlLines(string(data))
+	case "yaml":
+		err = yaml.Unmarshal(data, &raw)
+	case "json":
+		err = json.Unmarshal(data, &raw)
+	case "xml":
+		raw, err = xmlRaw(data)
+	case "ini":
+		raw, lines, err = iniRaw(string(data))
+	default:
+		return nil, nil, errors.Errorf("unknown configuration format '%s'", format)
+	}
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "problem parsing configuration")
+	}
+	return raw, lines, nil
+}
+
+// ParseFile parses a configuration file in the given format or, if empty,
+// in the format detected from its extension or else its content (see Parse)
+func ParseFile(f, format string) (*Configs, error) {
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem opening config file "+f)
+	}
+	if format == "" {
+		format = DetectFormat(f, data)
+	}
+	conf, err := Parse(data, format)
+	if err != nil {
+		return nil, errors.Wrap(err, "problem loading config file "+f)
+	}
+	return conf, nil
+}
+
+// decoder completes a decoded configuration
+// with the keys decoded in a generic way
+type decoder struct {
+	tag   string // struct tag holding the keys
+	lines *lineIndex
+	errs  *Errors
+	fill  bool // set the fields from the keys too, for the formats without typed decoding
+}
+
+// walk sets the fields of the struct v which are missing from raw, its decoded keys, to their default,
+// recursing in structs and slices of structs, and reports the keys which match no field.
+// If fill is set, the other fields are set from their key.
+func (d *decoder) walk(v reflect.Value, raw map[string]interface{}, path string) {
+	t := v.Type()
+	known := make(map[string]bool)
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := strings.Split(field.Tag.Get(d.tag), ",")[0]
+		if field.PkgPath != "" || key == "" || key == "-" {
+			continue
+		}
+		known[key] = true
+		value, ok := raw[key]
+		switch f := v.Field(i); {
+		case f.Kind() == reflect.Struct:
+			// the defaults of a missing table apply too
+			d.walk(f, asMap(value), path+key+".")
+		case !ok:
+			// a nil pointer stands for the default
+			if def, ok := field.Tag.Lookup("default"); ok && f.Kind() != reflect.Ptr {
+				setValue(f, def)
+			}
+		case f.Kind() == reflect.Slice && f.Type().Elem().Kind() == reflect.Struct:
+			items := asSlice(value)
+			if d.fill {
+				f.Set(reflect.MakeSlice(f.Type(), len(items), len(items)))
+			}
+			for j := 0; j < f.Len() && j < len(items); j++ {
+				d.walk(f.Index(j), asMap(items[j]), path+key+"."+strconv.Itoa(j)+".")
+			}
+		case d.fill:
+			if err := setValue(f, value); err != nil {
+				*d.errs = append(*d.errs, &Error{Line: d.lines.key(path, key), Err: errors.Wrapf(err, "key '%s'", displayPath(path+key))})
+			}
+		}
+	}
+
+	var unknown []string
+	for key := range raw {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	sort.Strings(unknown)
+	for _, key := range unknown {
+		*d.errs = append(*d.errs, &Error{Line: d.lines.key(path, key), Err: errors.Errorf("unknown key '%s'", displayPath(path+key))})
+	}
+	switch c := v.Addr().Interface().(type) {
+	case *Config:
+		c.line = d.lines.table(path)
+	case *Configs:
+		c.given = make(map[string]bool)
+		for i := 0; i < t.NumField(); i++ {
+			if _, ok := raw[strings.Split(t.Field(i).Tag.Get(d.tag), ",")[0]]; ok {
+				c.given[t.Field(i).Name] = true
+			}
+		}
+	}
+}
+
+// errorLine returns the line of a problem, or 0 if unknown
+func errorLine(err error) int {
+	if e, ok := err.(*Error); ok {
+		return e.Line
+	}
+	return 0
+}
+
+// setValue sets a field from a value decoded in a generic way: a string, or a list of strings for a slice
+func setValue(v reflect.Value, value interface{}) error {
+	switch v.Kind() {
+	case reflect.Ptr:
+		p := reflect.New(v.Type().Elem())
+		if err := setValue(p.Elem(), value); err != nil {
+			return err
+		}
+		v.Set(p)
+	case reflect.Slice:
+		items := asSlice(value)
+		if items == nil {
+			items = []interface{}{value}
+		}
+		s := reflect.MakeSlice(v.Type(), len(items), len(items))
+		for i := range items {
+			if err := setValue(s.Index(i), items[i]); err != nil {
+				return err
+			}
+		}
+		v.Set(s)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(fmt.Sprint(value))
+		if err != nil {
+			return errors.Errorf("'%v' is not a boolean", value)
+		}
+		v.SetBool(b)
+	case reflect.Int:
+		n, err := strconv.Atoi(fmt.Sprint(value))
+		if err != nil {
+			return errors.Errorf("'%v' is not an integer", value)
+		}
+		v.SetInt(int64(n))
+	case reflect.String:
+		v.SetString(fmt.Sprint(value))
+	}
+	return nil
+}
+
+// asMap returns a table decoded in a generic way as a map
+func asMap(value interface{}) map[string]interface{} {
+	switch m := value.(type) {
+	case map[string]interface{}:
+		return m
+	case map[interface{}]interface{}:
+		converted := make(map[string]interface{}, len(m))
+		for key, v := range m {
+			converted[fmt.Sprint(key)] = v
+		}
+		return converted
+	}
+	return nil
+}
+
+// asSlice returns a list of tables decoded in a generic way as a slice
+func asSlice(value interface{}) []interface{} {
+	switch s := value.(type) {
+	case []interface{}:
+		return s
+	case []map[string]interface{}:
+		items := make([]interface{}, len(s))
+		for i := range s {
+			items[i] = s[i]
+		}
+		return items
+	case map[string]interface{}:
+		// a list of a single table, in xml
+		return []interface{}{s}
+	}
+	return nil
+}
+
+// displayPath removes the indexes of the lists from a path
+func displayPath(path string) string {
+	var parts []string
+	for _, part := range strings.Split(strings.TrimSuffix(path, "."), ".") {
+		if _, err := strconv.Atoi(part); err != nil {
+			parts = append(parts, part)
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// lineIndex locates the tables and the keys of a configuration file
+type lineIndex struct {
+	lines  []string       // lines searched for the keys not indexed, in toml
+	tables map[string]int // index of the header line of each table, by path
+	keys   map[string]int // index of the line of each key, by path
+}
+
+// tomlHeader matches the header of a TOML table or array of tables
+var tomlHeader = regexp.MustCompile(`^\s*(\[\[?)\s*([A-Za-z0-9_.-]+)\s*\]\]?\s*(#.*)?$`)
+
+// tomlLines indexes the tables of a TOML configuration,
+// the elements of the arrays of tables being numbered in order
+func tomlLines(data string) *lineIndex {
+	li := &lineIndex{lines: strings.Split(data, "\n"), tables: make(map[string]int)}
+	current := make(map[string]string) // path of the last table with each header
+	count := make(map[string]int)      // number of elements of each array of tables
+	for i, line := range li.lines {
+		m := tomlHeader.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		header := m[2]
+		parent, name := "", header
+		if j := strings.LastIndex(header, "."); j >= 0 {
+			parent, name = current[header[:j]], header[j+1:]
+		}
+		path := parent + name + "."
+		if m[1] == "[[" {
+			array := parent + name
+			path = array + "." + strconv.Itoa(count[array]) + "."
+			count[array]++
+		}
+		current[header] = path
+		li.tables[path] = i
+	}
+	return li
+}
+
+// table returns the line of the header of the table at the given path, or 0 if unknown
+func (li *lineIndex) table(path string) int {
+	if li == nil {
+		return 0
+	}
+	if i, ok := li.tables[path]; ok {
+		return i + 1
+	}
+	return 0
+}
+
+// key returns the line of a key of the table at the given path, or 0 if unknown
+func (li *lineIndex) key(path, key string) int {
+	if li == nil {
+		return 0
+	}
+	if i, ok := li.keys[path+key]; ok {
+		return i + 1
+	}
+	start := 0
+	if path != "" {
+		i, ok := li.tables[path]
+		if !ok {
+			return 0
+		}
+		start = i + 1
+	}
+	re := regexp.MustCompile(`^\s*["']?` + regexp.QuoteMeta(key) + `["']?\s*=`)
+	for i := start; i < len(li.lines); i++ {
+		if tomlHeader.MatchString(li.lines[i]) {
+			break
+		}
+		if re.MatchString(li.lines[i]) {
+			return i + 1
+		}
+	}
+	return li.table(path)
+}
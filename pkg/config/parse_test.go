@@ -0,0 +1,367 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseDefaults(t *testing.T) {
+	conf, err := Parse([]byte(`
+[[pluck]]
+name = "title"
+activators = ["<title>"]
+deactivator = "<"
+
+[[pluck]]
+name = "links"
+activators = ["href=\""]
+deactivator = '"'
+enabled = false
+limit = 3
+
+[pluck.match]
+separator = ","
+split = false
+`), "toml")
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(conf.Pluck)) {
+		// the fields not given have their default
+		title := conf.Pluck[0]
+		assert.True(t, title.IsEnabled())
+		assert.True(t, title.Verbose)
+		assert.Equal(t, -1, title.Limit)
+		assert.Equal(t, "truncate", title.CaptureOverflow)
+		assert.Equal(t, "string", title.Type)
+		assert.Equal(t, Match{Mode: "any", Split: true}, title.Match)
+		assert.Equal(t, 2, title.Line())
+
+		links := conf.Pluck[1]
+		assert.False(t, links.IsEnabled())
+		assert.Equal(t, 3, links.Limit)
+		assert.Equal(t, Match{Mode: "any", Separator: ",", Split: false}, links.Match)
+		assert.Equal(t, 7, links.Line())
+	}
+	assert.True(t, conf.Verbose)
+	assert.False(t, conf.Debug)
+}
+
+func TestParseChildren(t *testing.T) {
+	conf, err := Parse([]byte(`[[pluck]]
+name = "songs"
+activators = ["<ul>"]
+deactivator = "</ul>"
+
+[[pluck.children]]
+name = "items"
+activators = ["<li>"]
+deactivator = "</li>"
+
+[[pluck.children.children]]
+name = "href"
+activators = ['href="']
+deactivator = '"'
+
+[[pluck.children.transforms]]
+name = "trim"
+
+[[pluck.children]]
+name = "disabled"
+enabled = false
+activators = ["<i>"]
+deactivator = "</i>"
+
+[[pluck]]
+name = "title"
+activators = ["<h1>"]
+deactivator = "</h1>"
+`), "toml")
+	assert.Nil(t, err)
+	if assert.Equal(t, 2, len(conf.Pluck)) && assert.Equal(t, 2, len(conf.Pluck[0].Children)) {
+		items := conf.Pluck[0].Children[0]
+		assert.Equal(t, 6, items.Line())
+		assert.Equal(t, 11, items.Children[0].Line())
+		assert.Equal(t, []Transform{{Name: "trim"}}, items.Transforms)
+		assert.True(t, items.Children[0].IsEnabled())
+		assert.Equal(t, 19, conf.Pluck[0].Children[1].Line())
+		assert.False(t, conf.Pluck[0].Children[1].IsEnabled())
+		assert.Equal(t, 25, conf.Pluck[1].Line())
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	_, err := Parse([]byte(`
+[[pluck]]
+name = "title"
+activator = ["<title>"]
+deactivator = "<"
+
+[pluck.match]
+mode = "any"
+separatr = ","
+
+[[pluck]]
+name = "links"
+activators = ["<a", "href"]
+permanent = 3
+
+[[pluck]]
+name = "title"
+record = "<div>"
+deactivator = "</div>"
+limit = -2
+
+[[pluck.children]]
+name = "price"
+activators = ["<i>"]
+
+[[pluck.transforms]]
+args = ["x"]
+`), "toml")
+	assert.EqualError(t, err, `9 problems in configuration:
+	line 4: unknown key 'pluck.activator'
+	line 9: unknown key 'pluck.match.separatr'
+	line 11: plucker links: missing deactivator
+	line 11: plucker links: permanent 3 exceeds the number of activators (2)
+	line 16: plucker title: duplicate name, already used at line 2
+	line 16: plucker title: a record cannot have activators or a deactivator
+	line 16: plucker title: invalid limit -2
+	line 16: plucker title: transform 0 has no name
+	line 22: plucker title.price: missing deactivator`)
+
+	_, err = Parse([]byte("[[pluck]]\nname = \"a\"\nactivators = [\"<b>\"\n"), "toml")
+	assert.Contains(t, err.Error(), "problem parsing configuration")
+	assert.Contains(t, err.Error(), "line 3")
+
+	_, err = Parse([]byte(`[[pluck]]`), "csv")
+	assert.EqualError(t, err, "unknown configuration format 'csv'")
+}
+
+func TestValidate(t *testing.T) {
+	conf := &Configs{Pluck: []Config{
+		{Activators: []string{"<b>"}, Deactivator: "</b>"},
+		{Activators: []string{"<b>"}, Deactivator: "</b>"},
+		{Name: "a", Activators: []string{"<b>"}, Deactivator: "</b>"},
+		{Name: "a", Activators: []string{"<b>"}, Permanent: -1},
+	}}
+	err := conf.Validate()
+	assert.EqualError(t, err, `3 problems in configuration:
+	plucker a: duplicate name, already used
+	plucker a: missing deactivator
+	plucker a: negative permanent -1`)
+	assert.Equal(t, 3, len(err.(Errors)))
+
+	conf.Pluck = conf.Pluck[:3]
+	assert.Nil(t, conf.Validate())
+}
+
+func TestParseFormats(t *testing.T) {
+	disabled := false
+	expected := []Config{{
+		Verbose:         true,
+		Name:            "songs",
+		Activators:      []string{"<ul", ">"},
+		Deactivator:     "</ul>",
+		Limit:           -1,
+		CaptureOverflow: "truncate",
+		Type:            "string",
+		Match:           Match{Mode: "any", Split: true},
+		Children: []Config{{
+			Verbose:         true,
+			Name:            "title",
+			Activators:      []string{"<li>"},
+			Deactivator:     " </li>",
+			Limit:           2,
+			CaptureOverflow: "truncate",
+			Type:            "string",
+			Match:           Match{Mode: "any", Split: true},
+			Transforms:      []Transform{{Name: "regex_replace", Args: []string{`\s+`, " "}}},
+		}},
+	}, {
+		Enabled:         &disabled,
+		Verbose:         true,
+		Name:            "year",
+		Activators:      []string{"<i>"},
+		Deactivator:     "</i>",
+		Limit:           -1,
+		CaptureOverflow: "truncate",
+		Type:            "int",
+		Match:           Match{Mode: "all", Split: true},
+		Blacklist:       []string{"19xx"},
+	}}
+
+	for format, data := range map[string]string{
+		"toml": `
+[[pluck]]
+name = "songs"
+activators = ["<ul", ">"]
+deactivator = "</ul>"
+
+[[pluck.children]]
+name = "title"
+activators = ["<li>"]
+deactivator = " </li>"
+limit = 2
+
+[[pluck.children.transforms]]
+name = "regex_replace"
+args = ['\s+', " "]
+
+[[pluck]]
+name = "year"
+enabled = false
+activators = ["<i>"]
+deactivator = "</i>"
+type = "int"
+blacklist = ["19xx"]
+
+[pluck.match]
+mode = "all"
+`,
+		"yaml": `
+pluck:
+- name: songs
+  activators: ["<ul", ">"]
+  deactivator: </ul>
+  children:
+  - name: title
+    activators: [<li>]
+    deactivator: " </li>"
+    limit: 2
+    transforms:
+    - name: regex_replace
+      args: ['\s+', " "]
+- name: year
+  enabled: false
+  activators: [<i>]
+  deactivator: </i>
+  type: int
+  blacklist: [19xx]
+  match:
+    mode: all
+`,
+		"json": `{"pluck": [
+	{"name": "songs", "activators": ["<ul", ">"], "deactivator": "</ul>", "children": [
+		{"name": "title", "activators": ["<li>"], "deactivator": " </li>", "limit": 2,
+		 "transforms": [{"name": "regex_replace", "args": ["\\s+", " "]}]}
+	]},
+	{"name": "year", "enabled": false, "activators": ["<i>"], "deactivator": "</i>", "type": "int", "blacklist": ["19xx"], "match": {"mode": "all"}}
+]}`,
+		"xml": `<configs>
+	<pluck>
+		<name>songs</name>
+		<activators>&lt;ul</activators>
+		<activators>&gt;</activators>
+		<deactivator>&lt;/ul&gt;</deactivator>
+		<children>
+			<name>title</name>
+			<activators>&lt;li&gt;</activators>
+			<deactivator> &lt;/li&gt;</deactivator>
+			<limit>2</limit>
+			<transforms>
+				<name>regex_replace</name>
+				<args>\s+</args>
+				<args> </args>
+			</transforms>
+		</children>
+	</pluck>
+	<pluck>
+		<name>year</name>
+		<enabled>false</enabled>
+		<activators>&lt;i&gt;</activators>
+		<deactivator>&lt;/i&gt;</deactivator>
+		<type>int</type>
+		<blacklist>19xx</blacklist>
+		<match><mode>all</mode></match>
+	</pluck>
+</configs>`,
+		"ini": `
+[pluck]
+name = songs
+activators = <ul
+activators = >
+deactivator = </ul>
+
+[pluck.children]
+name = title
+activators = <li>
+deactivator = " </li>"
+limit = 2
+
+[pluck.children.transforms]
+name = regex_replace
+args = \s+
+args = " "
+
+; a new plucker
+[pluck]
+name = year
+enabled = false
+activators = <i>
+deactivator = </i>
+type = int
+blacklist = 19xx
+
+[pluck.match]
+mode = all
+`,
+	} {
+		conf, err := Parse([]byte(data), format)
+		if !assert.Nil(t, err, format) {
+			continue
+		}
+		// the lines are only known in some formats
+		for i := range conf.Pluck {
+			conf.Pluck[i].line = 0
+			for j := range conf.Pluck[i].Children {
+				conf.Pluck[i].Children[j].line = 0
+			}
+		}
+		assert.Equal(t, expected, conf.Pluck, format)
+		assert.True(t, conf.Verbose, format)
+	}
+}
+
+func TestParseFormatErrors(t *testing.T) {
+	_, err := Parse([]byte("[pluck]\nname = a\nactivator = <b>\ndeactivator = </b>\nlimit = many\n\n[pluck.children]\nname = b\n"), "ini")
+	assert.EqualError(t, err, `3 problems in configuration:
+	line 3: unknown key 'pluck.activator'
+	line 5: key 'pluck.limit': 'many' is not an integer
+	line 7: plucker a.b: missing deactivator`)
+
+	_, err = Parse([]byte("pluck:\n- name: a\n  deactivatr: </b>\n"), "yml")
+	assert.EqualError(t, err, `2 problems in configuration:
+	unknown key 'pluck.deactivatr'
+	plucker a: missing deactivator`)
+
+	_, err = Parse([]byte(`{"pluck": [{"name": "a", "deactivator": "</b>", "limit": "3"}]}`), "json")
+	assert.Contains(t, err.Error(), "problem parsing configuration")
+
+	_, err = Parse([]byte("<configs><pluck><name>a</pluck></configs>"), "xml")
+	assert.Contains(t, err.Error(), "problem parsing configuration")
+
+	_, err = Parse([]byte("[pluck\nname = a\n"), "ini")
+	assert.EqualError(t, err, "problem parsing configuration: line 1: invalid section [pluck")
+}
+
+func TestParsePluckerKey(t *testing.T) {
+	// plucker, the former key of pluck, is still read
+	for format, data := range map[string]string{
+		"toml": "[[plucker]]\nname = \"a\"\ndeactivator = \"</b>\"\n\n[[pluck]]\nname = \"b\"\ndeactivator = \"</i>\"\n",
+		"yaml": "plucker:\n- name: a\n  deactivator: </b>\npluck:\n- name: b\n  deactivator: </i>\n",
+		"json": `{"plucker": [{"name": "a", "deactivator": "</b>"}], "pluck": [{"name": "b", "deactivator": "</i>"}]}`,
+		"xml":  "<configs><plucker><name>a</name><deactivator>&lt;/b&gt;</deactivator></plucker><pluck><name>b</name><deactivator>&lt;/i&gt;</deactivator></pluck></configs>",
+		"ini":  "[plucker]\nname = a\ndeactivator = </b>\n\n[pluck]\nname = b\ndeactivator = </i>\n",
+	} {
+		conf, err := Parse([]byte(data), format)
+		if assert.Nil(t, err, format) && assert.Equal(t, 2, len(conf.Pluck), format) {
+			assert.Equal(t, "b", conf.Pluck[0].Name, format)
+			assert.Equal(t, "a", conf.Pluck[1].Name, format)
+			assert.Equal(t, -1, conf.Pluck[1].Limit, format)
+			assert.Nil(t, conf.Plucker, format)
+		}
+	}
+
+	_, err := Parse([]byte("[[plucker]]\nname = \"a\"\n"), "toml")
+	assert.EqualError(t, err, "line 1: plucker a: missing deactivator")
+}
@@ -0,0 +1,293 @@
+package config
+
+import (
+	"fmt"
+	"io/ioutil"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+
+	// external
+	"github.com/pkg/errors"
+)
+
+// Schema is a node of a JSON Schema (draft 7)
+type Schema struct {
+	Schema               string             `json:"$schema,omitempty"`
+	Ref                  string             `json:"$ref,omitempty"`
+	Title                string             `json:"title,omitempty"`
+	Type                 string             `json:"type,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Enum                 []string           `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Definitions          map[string]*Schema `json:"definitions,omitempty"`
+
+	// required keys which another key makes optional, checked by Validate but not part of the schema
+	optional map[string]string
+}
+
+// schemaEnums lists the values allowed for some fields, by type and field name
+var schemaEnums = map[string][]string{
+	"Config.Type":            {string(TYPE_STRING), string(TYPE_INT), string(TYPE_FLOAT), string(TYPE_BOOL), string(TYPE_DATE), string(TYPE_URL)},
+	"Config.CaptureOverflow": {string(OVERFLOW_TRUNCATE), string(OVERFLOW_SKIP), string(OVERFLOW_ERROR)},
+	"Match.Mode":             {string(MATCH_ALL), string(MATCH_ANY), string(MATCH_PHRASE), string(MATCH_BOOLEAN)},
+}
+
+// schemaOptional lists the required fields which another field makes optional, by type and field name
+var schemaOptional = map[string]string{
+	"Config.Deactivator": "Record", // a record ends with the next one
+}
+
+// configRef refers to the definition of a plucker, which is recursive
+const configRef = "#/definitions/config"
+
+// NewSchema returns the JSON Schema of a configuration, derived from the json keys of the fields of Configs
+func NewSchema() *Schema {
+	return newSchema("json")
+}
+
+// newSchema returns the schema of a configuration with the keys of the given struct tag
+func newSchema(tag string) *Schema {
+	s := objectSchema(reflect.TypeOf(Configs{}), tag)
+	s.Schema = "http://json-schema.org/draft-07/schema#"
+	s.Title = "pluck configuration"
+	s.Definitions = map[string]*Schema{"config": objectSchema(reflect.TypeOf(Config{}), tag)}
+	return s
+}
+
+// schemaOf returns the schema of a type
+func schemaOf(t reflect.Type, tag string) *Schema {
+	switch t.Kind() {
+	case reflect.Ptr:
+		return schemaOf(t.Elem(), tag)
+	case reflect.Struct:
+		if t == reflect.TypeOf(Config{}) {
+			return &Schema{Ref: configRef}
+		}
+		return objectSchema(t, tag)
+	case reflect.Slice:
+		return &Schema{Type: "array", Items: schemaOf(t.Elem(), tag)}
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}
+	case reflect.Int:
+		return &Schema{Type: "integer"}
+	}
+	return &Schema{Type: "string"}
+}
+
+// objectSchema returns the schema of a struct, with a property per field with a key
+func objectSchema(t reflect.Type, tag string) *Schema {
+	closed := false
+	s := &Schema{Type: "object", Properties: make(map[string]*Schema), AdditionalProperties: &closed}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		key := strings.Split(field.Tag.Get(tag), ",")[0]
+		if field.PkgPath != "" || key == "" || key == "-" {
+			continue
+		}
+		p := schemaOf(field.Type, tag)
+		p.Enum = schemaEnums[t.Name()+"."+field.Name]
+		if def, ok := field.Tag.Lookup("default"); ok {
+			v := reflect.New(field.Type).Elem()
+			setValue(v, def)
+			p.Default = v.Interface()
+		}
+		s.Properties[key] = p
+		if field.Tag.Get("required") == "true" {
+			s.Required = append(s.Required, key)
+			if other, ok := t.FieldByName(schemaOptional[t.Name()+"."+field.Name]); ok {
+				if s.optional == nil {
+					s.optional = make(map[string]string)
+				}
+				s.optional[key] = strings.Split(other.Tag.Get(tag), ",")[0]
+			}
+		}
+	}
+	return s
+}
+
+// Validate checks a configuration decoded in a generic way, such as with encoding/json, against the schema,
+// returning all the problems found as Errors, with the path of the values at fault
+func (s *Schema) Validate(doc interface{}) error {
+	v := &validator{root: s}
+	v.check(s, doc, "", 0, "")
+	if len(v.errs) > 0 {
+		return v.errs
+	}
+	return nil
+}
+
+// ValidateFile checks a configuration file in the given format, or the detected one if empty, against the schema,
+// then as Parse does, returning all the problems found as Errors, with the path of the values at fault
+func ValidateFile(f, format string) error {
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		return errors.Wrap(err, "problem opening config file "+f)
+	}
+	if format == "" {
+		format = DetectFormat(f, data)
+	}
+	format = normalizeFormat(format)
+	raw, lines, err := decodeRaw(data, format)
+	if err == nil {
+		// xml and ini only have strings, and xml cannot tell a list of one element
+		v := &validator{root: newSchema(format), lenient: format == "xml" || format == "ini", lines: lines}
+		v.check(v.root, raw, "", 0, "")
+		sort.SliceStable(v.errs, func(i, j int) bool { return errorLine(v.errs[i]) < errorLine(v.errs[j]) })
+		if len(v.errs) > 0 {
+			err = v.errs
+		} else {
+			_, err = Parse(data, format)
+		}
+	}
+	return errors.Wrap(err, "problem validating config file "+f)
+}
+
+// validator checks values against a schema
+type validator struct {
+	root    *Schema
+	lenient bool // accept strings for the other types, and single values for lists
+	lines   *lineIndex
+	errs    Errors
+}
+
+// check checks a value at the given path, with its line and the path of its table in the line index
+func (v *validator) check(s *Schema, value interface{}, path string, line int, table string) {
+	if s.Ref == configRef {
+		s = v.root.Definitions["config"]
+	}
+	switch s.Type {
+	case "object":
+		m := asMap(value)
+		if m == nil {
+			v.fail(path, line, "expected an object, got %s", kindOf(value))
+			return
+		}
+		keys := make([]string, 0, len(m))
+		for key := range m {
+			keys = append(keys, key)
+		}
+		sort.Strings(keys)
+		for _, key := range keys {
+			p, ok := s.Properties[key]
+			if !ok {
+				v.fail(joinPath(path, key), v.lines.key(table, key), "unknown key")
+				continue
+			}
+			v.check(p, m[key], joinPath(path, key), v.lines.key(table, key), table+key+".")
+		}
+		for _, key := range s.Required {
+			if _, ok := m[key]; ok {
+				continue
+			}
+			if other, ok := s.optional[key]; ok && m[other] != nil {
+				continue
+			}
+			v.fail(path, line, "missing key '%s'", key)
+		}
+	case "array":
+		var items []interface{}
+		switch value.(type) {
+		case []interface{}, []map[string]interface{}:
+			items = asSlice(value)
+		default:
+			if !v.lenient || value == nil {
+				v.fail(path, line, "expected an array, got %s", kindOf(value))
+				return
+			}
+			items = []interface{}{value}
+		}
+		for i, item := range items {
+			itemTable := table + strconv.Itoa(i) + "."
+			itemLine := line
+			if l := v.lines.table(itemTable); l > 0 {
+				itemLine = l
+			}
+			v.check(s.Items, item, fmt.Sprintf("%s[%d]", path, i), itemLine, itemTable)
+		}
+	case "string":
+		str, ok := value.(string)
+		if !ok {
+			v.fail(path, line, "expected a string, got %s", kindOf(value))
+			return
+		}
+		if len(s.Enum) > 0 && !contains(s.Enum, str) {
+			v.fail(path, line, "'%s' is not one of %s", str, strings.Join(s.Enum, ", "))
+		}
+	case "integer":
+		switch n := value.(type) {
+		case int, int64:
+			return
+		case float64:
+			if n == float64(int64(n)) {
+				return
+			}
+		case string:
+			if _, err := strconv.Atoi(n); err == nil && v.lenient {
+				return
+			}
+		}
+		v.fail(path, line, "expected an integer, got %s", kindOf(value))
+	case "boolean":
+		switch b := value.(type) {
+		case bool:
+			return
+		case string:
+			if _, err := strconv.ParseBool(b); err == nil && v.lenient {
+				return
+			}
+		}
+		v.fail(path, line, "expected a boolean, got %s", kindOf(value))
+	}
+}
+
+// fail records a problem with the value at the given path
+func (v *validator) fail(path string, line int, format string, args ...interface{}) {
+	if path == "" {
+		path = "configuration"
+	}
+	v.errs = append(v.errs, &Error{Line: line, Err: errors.Errorf("%s: %s", path, fmt.Sprintf(format, args...))})
+}
+
+// joinPath returns the path of a key of an object
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// kindOf names the JSON type of a value decoded in a generic way
+func kindOf(value interface{}) string {
+	switch value.(type) {
+	case nil:
+		return "null"
+	case string:
+		return "a string"
+	case bool:
+		return "a boolean"
+	case int, int64:
+		return "an integer"
+	case float64:
+		return "a number"
+	case []interface{}, []map[string]interface{}:
+		return "an array"
+	case map[string]interface{}, map[interface{}]interface{}:
+		return "an object"
+	}
+	return fmt.Sprintf("a %T", value)
+}
+
+// contains tells whether a list holds a value
+func contains(list []string, value string) bool {
+	for _, s := range list {
+		if s == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,111 @@
+package config
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNewSchema(t *testing.T) {
+	s := NewSchema()
+	assert.Equal(t, "object", s.Type)
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/definitions/config"}}, s.Properties["pluck"])
+
+	config := s.Definitions["config"]
+	// every field with a json key is described
+	typ, keys := reflect.TypeOf(Config{}), 0
+	for i := 0; i < typ.NumField(); i++ {
+		if key := strings.Split(typ.Field(i).Tag.Get("json"), ",")[0]; key != "" {
+			assert.Contains(t, config.Properties, key)
+			keys++
+		}
+	}
+	assert.Equal(t, keys, len(config.Properties))
+	assert.Contains(t, config.Properties, "blacklist")
+	assert.Equal(t, &Schema{Type: "integer", Default: -1}, config.Properties["limit"])
+	assert.Equal(t, &Schema{Type: "array", Items: &Schema{Ref: "#/definitions/config"}}, config.Properties["children"])
+	assert.Equal(t, []string{"string", "int", "float", "bool", "date", "url"}, config.Properties["type"].Enum)
+	assert.Equal(t, "string", config.Properties["type"].Default)
+	assert.Equal(t, []string{"all", "any", "phrase", "boolean"}, config.Properties["match"].Properties["mode"].Enum)
+	assert.Equal(t, false, *config.Properties["match"].AdditionalProperties)
+	assert.Equal(t, []string{"name", "deactivator"}, config.Required)
+	assert.Nil(t, s.Required)
+
+	_, err := json.Marshal(s)
+	assert.Nil(t, err)
+}
+
+func TestSchemaValidate(t *testing.T) {
+	var doc interface{}
+	json.Unmarshal([]byte(`{
+		"verbose": "yes",
+		"pluck": [
+			{"name": "a", "activators": ["<b>"], "deactivator": "</b>", "limit": 2.5},
+			{"name": "b", "record": "<tr>", "capture_overflow": "wrap", "children": [
+				{"name": "c", "activators": "<i>", "match": {"mode": "any", "splitt": true}}
+			]}
+		]
+	}`), &doc)
+	// a record needs no deactivator
+	assert.EqualError(t, NewSchema().Validate(doc), `6 problems in configuration:
+	pluck[0].limit: expected an integer, got a number
+	pluck[1].capture_overflow: 'wrap' is not one of truncate, skip, error
+	pluck[1].children[0].activators: expected an array, got a string
+	pluck[1].children[0].match.splitt: unknown key
+	pluck[1].children[0]: missing key 'deactivator'
+	verbose: expected a boolean, got a string`)
+
+	assert.EqualError(t, NewSchema().Validate(nil), "configuration: expected an object, got null")
+	json.Unmarshal([]byte(`{"pluck": [{"name": "a", "deactivator": "</b>", "limit": 3}]}`), &doc)
+	assert.Nil(t, NewSchema().Validate(doc))
+}
+
+func TestValidateFile(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pluck")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+	write := func(name, data string) string {
+		f := filepath.Join(dir, name)
+		ioutil.WriteFile(f, []byte(data), 0644)
+		return f
+	}
+
+	f := write("bad.toml", `
+[[pluck]]
+name = "a"
+activator = ["<b>"]
+limit = "3"
+type = "number"
+
+[pluck.match]
+split = 1
+`)
+	assert.EqualError(t, ValidateFile(f, ""), "problem validating config file "+f+`: 5 problems in configuration:
+	line 2: pluck[0]: missing key 'deactivator'
+	line 4: pluck[0].activator: unknown key
+	line 5: pluck[0].limit: expected an integer, got a string
+	line 6: pluck[0].type: 'number' is not one of string, int, float, bool, date, url
+	line 9: pluck[0].match.split: expected a boolean, got an integer`)
+
+	// the pluckers are checked once the schema is met
+	f = write("plucker.yaml", "pluck:\n- name: a\n  activators: [<b>]\n  deactivator: </b>\n- name: a\n  record: <tr>\n")
+	assert.EqualError(t, ValidateFile(f, ""), "problem validating config file "+f+": plucker a: duplicate name, already used")
+
+	// xml and ini only have strings
+	f = write("config.ini", "[pluck]\nname = a\nactivators = <b>\ndeactivator = </b>\nlimit = 3\ncaptureLimit = x\n")
+	assert.EqualError(t, ValidateFile(f, ""), "problem validating config file "+f+": line 6: pluck[0].captureLimit: expected an integer, got a string")
+	f = write("config.xml", "<configs><pluck><name>a</name><activators>&lt;b&gt;</activators><deactivator>&lt;/b&gt;</deactivator><limit>3</limit><captureLimit>100</captureLimit></pluck></configs>")
+	assert.Nil(t, ValidateFile(f, ""))
+
+	assert.Nil(t, ValidateFile("../../tests/song.toml", ""))
+	assert.NotNil(t, ValidateFile(filepath.Join(dir, "missing.toml"), ""))
+	assert.EqualError(t, ValidateFile(f, "csv"), "problem validating config file "+f+": unknown configuration format 'csv'")
+}
@@ -0,0 +1,13 @@
+package config
+
+// Transform specifies a transform applied to each plucked occurence (see the transform package)
+type Transform struct {
+
+	// name of a registered transform, e.g. trim, lowercase or regex_replace
+	Name string `required:"true" json:"name" yaml:"name" toml:"name" xml:"name" ini:"name"`
+
+	// arguments of the transform, e.g. the pattern and the replacement of regex_replace
+	Args []string `json:"args,omitempty" yaml:"args,omitempty" toml:"args,omitempty" xml:"args,omitempty" ini:"args,omitempty"`
+
+	//-- End
+}
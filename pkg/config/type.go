@@ -0,0 +1,14 @@
+package config
+
+// ValueType specifies the type a plucked occurence is converted to
+type ValueType string
+
+// Enum list of all available value types
+const (
+	TYPE_STRING ValueType = "string" // keeps the plucked occurence as text
+	TYPE_INT    ValueType = "int"    // converts the first number of the plucked occurence to an integer
+	TYPE_FLOAT  ValueType = "float"  // converts the first number of the plucked occurence to a floating-point number
+	TYPE_BOOL   ValueType = "bool"   // converts true/false, yes/no, on/off or 1/0 to a boolean
+	TYPE_DATE   ValueType = "date"   // parses a date with the layout given by the format, RFC3339 by default
+	TYPE_URL    ValueType = "url"    // resolves the plucked occurence to an absolute URL
+)
@@ -0,0 +1,115 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	// external
+	"github.com/pkg/errors"
+)
+
+// Error is a problem found in a configuration, at the given line of its file if known
+type Error struct {
+	Line int
+	Err  error
+}
+
+func (e *Error) Error() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("line %d: %v", e.Line, e.Err)
+	}
+	return e.Err.Error()
+}
+
+// Errors gathers all the problems found in a configuration
+type Errors []error
+
+func (e Errors) Error() string {
+	if len(e) == 1 {
+		return e[0].Error()
+	}
+	messages := make([]string, len(e))
+	for i, err := range e {
+		messages[i] = err.Error()
+	}
+	return fmt.Sprintf("%d problems in configuration:\n\t%s", len(e), strings.Join(messages, "\n\t"))
+}
+
+// IsEnabled reports whether the plucker is enabled: unless Enabled is set to false
+func (c Config) IsEnabled() bool {
+	return c.Enabled == nil || *c.Enabled
+}
+
+// Line returns the line of the plucker in the configuration file it was loaded from, or 0 if unknown
+func (c Config) Line() int {
+	return c.line
+}
+
+// Validate checks the pluckers of a configuration, disabled ones included, and returns all the problems found as Errors, or nil
+func (c *Configs) Validate() error {
+	var errs Errors
+	validate(c.Pluck, "", &errs)
+	if len(errs) > 0 {
+		return errs
+	}
+	return nil
+}
+
+// validate checks a list of pluckers, named after their parent if they are children
+func validate(configs []Config, parent string, errs *Errors) {
+	lines := make(map[string]int)
+	for i, c := range configs {
+		name := c.Name
+		if name == "" {
+			name = strconv.Itoa(i)
+		}
+		if parent != "" {
+			name = parent + "." + name
+		}
+		if line, dup := lines[name]; dup && c.Name != "" {
+			*errs = append(*errs, &Error{Line: c.line, Err: errors.Errorf("plucker %s: duplicate name, already used%s", name, atLine(line))})
+		}
+		lines[name] = c.line
+		for _, problem := range c.problems() {
+			*errs = append(*errs, &Error{Line: c.line, Err: errors.Errorf("plucker %s: %s", name, problem)})
+		}
+		validate(c.Children, name, errs)
+	}
+}
+
+// problems returns the problems of a single plucker
+func (c *Config) problems() (problems []string) {
+	if c.Record != "" {
+		if len(c.Activators) > 0 || c.Deactivator != "" {
+			problems = append(problems, "a record cannot have activators or a deactivator")
+		}
+	} else {
+		if c.Deactivator == "" {
+			problems = append(problems, "missing deactivator")
+		}
+		if c.Permanent > len(c.Activators) {
+			problems = append(problems, fmt.Sprintf("permanent %d exceeds the number of activators (%d)", c.Permanent, len(c.Activators)))
+		}
+	}
+	if c.Permanent < 0 {
+		problems = append(problems, fmt.Sprintf("negative permanent %d", c.Permanent))
+	}
+	if c.Limit < -1 {
+		problems = append(problems, fmt.Sprintf("invalid limit %d", c.Limit))
+	}
+	for i, t := range c.Transforms {
+		if t.Name == "" {
+			problems = append(problems, fmt.Sprintf("transform %d has no name", i))
+		}
+	}
+	return
+}
+
+// atLine formats a line number, if known
+func atLine(line int) string {
+	if line > 0 {
+		return fmt.Sprintf(" at line %d", line)
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"bytes"
+	"encoding/xml"
+	"io"
+)
+
+// xmlRaw decodes an XML configuration in a generic way: under the root element, the elements
+// with child elements are tables, the others values, and an element given several times a list
+func xmlRaw(data []byte) (map[string]interface{}, error) {
+	d := xml.NewDecoder(bytes.NewReader(data))
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			return nil, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		if _, ok := tok.(xml.StartElement); ok {
+			root, err := xmlElement(d)
+			if err != nil {
+				return nil, err
+			}
+			return asMap(root), nil
+		}
+	}
+}
+
+// xmlElement decodes the rest of an element, once its start is read
+func xmlElement(d *xml.Decoder) (interface{}, error) {
+	var table map[string]interface{}
+	var text []byte
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			return nil, err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			value, err := xmlElement(d)
+			if err != nil {
+				return nil, err
+			}
+			if table == nil {
+				table = make(map[string]interface{})
+			}
+			switch prev := table[t.Name.Local].(type) {
+			case nil:
+				table[t.Name.Local] = value
+			case []interface{}:
+				table[t.Name.Local] = append(prev, value)
+			default:
+				table[t.Name.Local] = []interface{}{prev, value}
+			}
+		case xml.CharData:
+			text = append(text, t...)
+		case xml.EndElement:
+			if table != nil {
+				return table, nil
+			}
+			return string(text), nil
+		}
+	}
+}
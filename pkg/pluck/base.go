@@ -0,0 +1,120 @@
+package pluck
+
+import (
+	"bytes"
+	"html"
+	"net/url"
+	"regexp"
+	"strings"
+
+	// external
+	"github.com/pkg/errors"
+
+	// internal
+	transform "github.com/sniperkit/pluck/pkg/transform"
+)
+
+// maxBaseTag is the maximum length of a <base> tag looked for.
+const maxBaseTag = 4096
+
+// baseHref matches the href attribute of a <base> tag.
+var baseHref = regexp.MustCompile(`(?i)\shref\s*=\s*(?:"([^"]*)"|'([^']*)'|([^\s"'>]+))`)
+
+// baseScanner looks for the <base href> of an HTML page read in chunks,
+// until the <body> tag, and resolves the base URL of env with it.
+type baseScanner struct {
+	env  *transform.Env
+	tail []byte // beginning of a tag which may end in the next chunk
+	done bool
+}
+
+// newBaseScanner returns a scanner updating env, or nil
+// if env is nil since there is no page URL to resolve.
+func newBaseScanner(env *transform.Env) *baseScanner {
+	if env == nil || env.BaseURL == nil {
+		return nil
+	}
+	return &baseScanner{env: env}
+}
+
+// scan looks for the <base> tag in the next chunk of the page.
+func (bs *baseScanner) scan(chunk []byte) {
+	if bs == nil || bs.done {
+		return
+	}
+	b := chunk
+	if len(bs.tail) > 0 {
+		b = append(bs.tail, chunk...)
+		bs.tail = nil
+	}
+	for {
+		i := bytes.IndexByte(b, '<')
+		if i < 0 {
+			return
+		}
+		b = b[i:]
+		if len(b) <= len("<body") {
+			bs.tail = append([]byte(nil), b...)
+			return
+		}
+		switch {
+		case isTag(b, "<body"):
+			bs.done = true
+			return
+		case isTag(b, "<base"):
+			end := bytes.IndexByte(b, '>')
+			if end < 0 {
+				if len(b) < maxBaseTag {
+					bs.tail = append([]byte(nil), b...)
+				}
+				return
+			}
+			if bs.resolve(b[len("<base"):end]) {
+				bs.done = true
+				return
+			}
+		}
+		b = b[1:]
+	}
+}
+
+// isTag reports whether b starts with the given tag name,
+// followed by white space, '/' or '>'.
+func isTag(b []byte, name string) bool {
+	if !bytes.EqualFold(b[:len(name)], []byte(name)) {
+		return false
+	}
+	switch b[len(name)] {
+	case ' ', '\t', '\r', '\n', '\f', '/', '>':
+		return true
+	}
+	return false
+}
+
+// resolve sets the base URL from the attributes of a <base> tag,
+// returning whether the tag had an href attribute.
+func (bs *baseScanner) resolve(attributes []byte) bool {
+	m := baseHref.FindSubmatch(attributes)
+	if m == nil {
+		return false
+	}
+	href := string(m[1]) + string(m[2]) + string(m[3])
+	ref, err := url.Parse(strings.TrimSpace(html.UnescapeString(href)))
+	if err == nil {
+		bs.env.BaseURL = bs.env.BaseURL.ResolveReference(ref)
+	}
+	return true
+}
+
+// resolve returns a captured text resolved as a URL against
+// the base URL of the page, if any.
+func resolve(text string, env *transform.Env) (string, error) {
+	if env == nil || env.BaseURL == nil {
+		return text, nil
+	}
+	ref, err := url.Parse(text)
+	if err != nil {
+		return text, errors.Wrap(err, "resolve")
+	}
+	return env.BaseURL.ResolveReference(ref).String(), nil
+}
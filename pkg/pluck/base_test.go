@@ -0,0 +1,99 @@
+package pluck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+	transform "github.com/sniperkit/pluck/pkg/transform"
+)
+
+func TestBaseScanner(t *testing.T) {
+	for _, test := range []struct {
+		page string
+		base string
+	}{
+		{`<head><base href="/static/"></head>`, "http://example.com/static/"},
+		{`<HEAD><BASE target=_blank HREF='../up/'></HEAD>`, "http://example.com/up/"},
+		{`<base href=https://cdn.org/a/b/>`, "https://cdn.org/a/b/"},
+		{`<base href="?a=1&amp;b=2">`, "http://example.com/dir/page.html?a=1&b=2"},
+		{`<base target="_blank"><base href="/second/">`, "http://example.com/second/"},
+		{`<basefont href="/no/"><p>`, "http://example.com/dir/page.html"},
+		{`<body><base href="/late/">`, "http://example.com/dir/page.html"},
+		{`no tags at all`, "http://example.com/dir/page.html"},
+	} {
+		// whatever the chunks the page is read in
+		for _, size := range []int{1, 3, 7, len(test.page)} {
+			page, _ := url.Parse("http://example.com/dir/page.html")
+			env := &transform.Env{BaseURL: page}
+			bs := newBaseScanner(env)
+			for b := []byte(test.page); len(b) > 0; {
+				n := size
+				if n > len(b) {
+					n = len(b)
+				}
+				bs.scan(b[:n])
+				b = b[n:]
+			}
+			assert.Equal(t, test.base, env.BaseURL.String(), "%s in chunks of %d", test.page, size)
+		}
+	}
+
+	// nothing to resolve without the URL of the page
+	assert.Nil(t, newBaseScanner(nil))
+	assert.Nil(t, newBaseScanner(&transform.Env{}))
+}
+
+func TestResolve(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/news/world/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<a href="/section/world">World</a> <a href="story.html">Story</a> <a href="https://other.org/">Other</a>`))
+	})
+	mux.HandleFunc("/based/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<html><head><base href="/assets/"></head><body><a href="story.html">Story</a></body></html>`))
+	})
+	mux.Handle("/world", http.RedirectHandler("/news/world/", http.StatusFound))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		p.Add(config.Config{Activators: []string{`<a href="`}, Deactivator: `"`, Resolve: true})
+
+		// against the URL after the redirects
+		assert.Nil(t, p.PluckURL(ts.URL+"/world", stream))
+		assert.Equal(t, []string{ts.URL + "/section/world", ts.URL + "/news/world/story.html", "https://other.org/"}, p.TypedResult().Strings("0"))
+
+		// against the <base href> of the page
+		assert.Nil(t, p.PluckURL(ts.URL+"/based/", stream))
+		assert.Equal(t, []string{ts.URL + "/assets/story.html"}, p.TypedResult().Strings("0"))
+
+		// unchanged without the URL of the page
+		assert.Nil(t, p.PluckString(`<a href="/section/world">`, stream))
+		assert.Equal(t, []string{"/section/world"}, p.TypedResult().Strings("0"))
+	}
+
+	p, _ := New()
+	err := p.LoadFromString(`
+[[pluck]]
+name = "links"
+activators = ['<a href="']
+deactivator = '"'
+resolve = true
+`)
+	assert.Nil(t, err)
+	assert.Nil(t, p.PluckURL(ts.URL+"/based/"))
+	assert.Equal(t, `{"links":"`+ts.URL+`/assets/story.html"}`, p.ResultJSON())
+
+	// the url type and the url_resolve transform use the <base href> too
+	p, _ = New()
+	p.Add(config.Config{Name: "typed", Activators: []string{`<a href="`}, Deactivator: `"`, Type: "url"})
+	p.Add(config.Config{Name: "transformed", Activators: []string{`<a href="`}, Deactivator: `"`, Transforms: []config.Transform{{Name: "url_resolve"}}})
+	assert.Nil(t, p.PluckURL(ts.URL+"/based/", true))
+	assert.Equal(t, ts.URL+"/assets/story.html", p.Result()["typed"])
+	assert.Equal(t, ts.URL+"/assets/story.html", p.Result()["transformed"])
+}
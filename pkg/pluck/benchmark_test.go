@@ -0,0 +1,78 @@
+package pluck
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"testing"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+// benchmarkPluckers are the pluckers of the benchmarks: "single"
+// uses the first one, "multiple" the literal ones, "regex" the ones
+// using regular expressions and "regex-any" one ending with any
+// character, which is looked for in a window after almost every byte.
+var benchmarkPluckers = map[string][]config.Config{
+	"multiple": {
+		{Name: "title", Activators: []string{"<title>"}, Deactivator: "</title>"},
+		{Name: "links", Activators: []string{"<a", "href=\""}, Deactivator: "\"", Limit: -1},
+		{Name: "options", Activators: []string{`<option class="level-0" `, ">"}, Deactivator: "<", Limit: -1},
+		{Name: "songs", Activators: []string{"<span>Song of the Day", ">Song of the Day: "}, Permanent: 1, Deactivator: "<", Limit: -1},
+		{Name: "paragraphs", Activators: []string{"<p"}, Deactivator: "</p>", Limit: -1},
+	},
+	"regex": {
+		{Name: "title", Activators: []string{`(?i)<title>`}, Deactivator: `</title\s*>`, Regex: true},
+		{Name: "images", Activators: []string{`<img[^>]+src="`}, Deactivator: `"`, Regex: true, Limit: -1},
+	},
+	"regex-any": {
+		{Name: "links", Activators: []string{`<a.`}, Deactivator: `>`, Regex: true, Limit: -1},
+	},
+}
+
+func init() {
+	benchmarkPluckers["single"] = benchmarkPluckers["multiple"][:1]
+}
+
+// BenchmarkPluck plucks the files of the tests directory with a
+// single or multiple pluckers, in parallel or streaming, with and
+// without sanitizing the captures. The large benchmarks are in
+// plucker_test.go.
+func BenchmarkPluck(b *testing.B) {
+	for _, file := range []string{"test.txt", "song.html"} {
+		data, err := ioutil.ReadFile("../../tests/" + file)
+		if err != nil {
+			b.Fatal(err)
+		}
+		for _, pluckers := range []string{"single", "multiple", "regex", "regex-any"} {
+			for _, mode := range []string{"pluck", "stream"} {
+				for _, sanitize := range []bool{false, true} {
+					name := fmt.Sprintf("%s/%s/%s/sanitize=%v", file, pluckers, mode, sanitize)
+					b.Run(name, func(b *testing.B) {
+						p := newBenchmarkPlucker(b, benchmarkPluckers[pluckers], sanitize)
+						b.SetBytes(int64(len(data)))
+						b.ReportAllocs()
+						b.ResetTimer()
+						for n := 0; n < b.N; n++ {
+							if _, err := p.Extract(bytes.NewReader(data), mode == "stream"); err != nil {
+								b.Fatal(err)
+							}
+						}
+					})
+				}
+			}
+		}
+	}
+}
+
+func newBenchmarkPlucker(b *testing.B, pluckers []config.Config, sanitize bool) *Plucker {
+	p, _ := New()
+	p.Verbose(false)
+	for _, c := range pluckers {
+		c.Sanitize = sanitize
+		if err := p.Add(c); err != nil {
+			b.Fatal(err)
+		}
+	}
+	return p
+}
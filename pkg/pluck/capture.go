@@ -0,0 +1,156 @@
+package pluck
+
+import (
+	"bytes"
+	"fmt"
+	"unicode"
+
+	// external
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+
+	// internal
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+// CaptureOverflowError is returned when a capture is longer than the
+// capture limit of a plucker using the "error" overflow mode.
+type CaptureOverflowError struct {
+	Name  string // name of the plucker
+	Limit int    // capture limit of the plucker
+	Size  int    // size of the capture
+}
+
+func (e *CaptureOverflowError) Error() string {
+	return fmt.Sprintf("plucker %s: capture of %d bytes exceeds the capture limit of %d bytes", e.Name, e.Size, e.Limit)
+}
+
+// compileCapture checks the capture limit of a plucker
+// and fills the corresponding fields of the unit.
+func (u *pluckUnit) compileCapture(c config.Config) (err error) {
+	if c.CaptureLimit < 0 {
+		return errors.Errorf("negative capture limit %d", c.CaptureLimit)
+	}
+	u.captureLimit = c.CaptureLimit
+	u.overflow = config.OverflowMode(c.CaptureOverflow)
+	switch u.overflow {
+	case "":
+		u.overflow = config.OVERFLOW_TRUNCATE
+	case config.OVERFLOW_TRUNCATE, config.OVERFLOW_SKIP, config.OVERFLOW_ERROR:
+	default:
+		return errors.Errorf("unknown capture overflow mode '%s'", c.CaptureOverflow)
+	}
+	return
+}
+
+// position locates a byte of the input.
+type position struct {
+	offset int // byte offset, starting at 0
+	line   int // line number, starting at 1
+	column int // byte offset in the line, starting at 1
+}
+
+// skip returns the position following the bytes b.
+func (p position) skip(b []byte) position {
+	p.offset += len(b)
+	if n := bytes.Count(b, newline); n > 0 {
+		p.line += n
+		p.column = len(b) - bytes.LastIndexByte(b, '\n')
+	} else {
+		p.column += len(b)
+	}
+	return p
+}
+
+var newline = []byte("\n")
+
+// span is a capture, or a piece of it, with the position
+// of the input bytes it comes from.
+type span struct {
+	text   []byte
+	start  position // position of the capture in the input
+	length int      // length of the capture in the input
+	// mapped is set when text[i] is the input byte at start.offset+i,
+	// which is no longer the case once the capture is sanitized
+	mapped bool
+	// raw is the capture as found at rawStart in the input
+	raw      []byte
+	rawStart position
+	// truncated is set when the capture exceeded the capture limit
+	truncated bool
+}
+
+// appendCapture adds the bytes found at the index k of the current
+// chunk to the current capture, only buffering the bytes allowed by
+// the capture limit.
+func (s *unitState) appendCapture(b []byte, k int) {
+	if s.captureN == 0 {
+		s.captureStart = s.positionAt(k)
+	}
+	s.captureN += len(b)
+	if limit := s.unit.captureLimit; limit > 0 && len(s.captureByte)+len(b) > limit {
+		b = b[:limit-len(s.captureByte)]
+	}
+	s.captureByte = append(s.captureByte, b...)
+}
+
+// takeCapture finishes the current capture, whose last n bytes are
+// the deactivator, and returns a copy of it. It returns false if the
+// capture overflowed and must be dropped.
+func (s *unitState) takeCapture(n int) (sp span, ok bool) {
+	size := s.captureN - n
+	buffered := s.captureByte
+	if len(buffered) > size {
+		buffered = buffered[:size]
+	}
+	s.captureByte = s.captureByte[:0]
+	s.captureN = 0
+
+	u := s.unit
+	if u.captureLimit > 0 && size > u.captureLimit {
+		switch u.overflow {
+		case config.OVERFLOW_SKIP:
+			log.Warnf("plucker %s: skipped a capture of %d bytes", u.config.Name, size)
+			s.numSkipped++
+			return span{}, false
+		case config.OVERFLOW_ERROR:
+			s.err = &CaptureOverflowError{Name: u.config.Name, Limit: u.captureLimit, Size: size}
+			s.isFinished = true
+			return span{}, false
+		default:
+			log.Warnf("plucker %s: truncated a capture of %d bytes", u.config.Name, size)
+			sp.truncated = true
+		}
+	}
+	sp.text = make([]byte, len(buffered))
+	sp.start = s.captureStart
+	sp.length = size
+	sp.mapped = true
+	copy(sp.text, buffered)
+	sp.raw, sp.rawStart = sp.text, sp.start
+	return sp, true
+}
+
+// clean sanitizes a capture if needed and trims the white space
+// around it, keeping its position in the input up to date.
+func (u *pluckUnit) clean(sp span) span {
+	if u.config.Sanitize {
+		sp.text = sanitize(sp.text)
+		sp.mapped = false
+	}
+	trimmed := bytes.TrimSpace(sp.text)
+	if !sp.mapped || len(trimmed) == 0 {
+		sp.text = trimmed
+		return sp
+	}
+	leading := len(sp.text) - len(bytes.TrimLeftFunc(sp.text, unicode.IsSpace))
+	sp.start = sp.start.skip(sp.text[:leading])
+	if len(sp.text) < sp.length {
+		// truncated: the end of the capture is not in the text
+		sp.length -= leading
+	} else {
+		sp.length = len(trimmed)
+	}
+	sp.text = trimmed
+	return sp
+}
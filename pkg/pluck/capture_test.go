@@ -0,0 +1,93 @@
+package pluck
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+func TestCaptureLarge(t *testing.T) {
+	blob := strings.Repeat("x", 250000)
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		p.Add(config.Config{
+			Activators:  []string{"<script>"},
+			Deactivator: "</script>",
+		})
+		assert.Nil(t, p.PluckString("<script>"+blob+"</script>", stream))
+		assert.Equal(t, blob, p.Result()["0"])
+	}
+}
+
+func TestCaptureOverflow(t *testing.T) {
+	input := "<b>short</b><b>this one is too long</b><b>end</b>"
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		p.Add(config.Config{
+			Activators:   []string{"<b>"},
+			Deactivator:  "</b>",
+			CaptureLimit: 8,
+		})
+		assert.Nil(t, p.PluckString(input, stream))
+		r := p.TypedResult()
+		assert.Equal(t, `{"0":["short","this one","end"]}`, p.ResultJSON())
+		if captures := r.Get("0"); assert.Len(t, captures, 3) {
+			assert.False(t, captures[0].Truncated)
+			assert.True(t, captures[1].Truncated)
+			assert.Equal(t, 20, captures[1].Length)
+			assert.False(t, captures[2].Truncated)
+		}
+		assert.Equal(t, 0, r.Skipped("0"))
+
+		p, _ = New()
+		p.Add(config.Config{
+			Activators:      []string{"<b>"},
+			Deactivator:     "</b>",
+			CaptureLimit:    8,
+			CaptureOverflow: "skip",
+		})
+		assert.Nil(t, p.PluckString(input, stream))
+		r = p.TypedResult()
+		assert.Equal(t, `{"0":["short","end"]}`, p.ResultJSON())
+		assert.Equal(t, 1, r.Skipped("0"))
+		assert.Equal(t, 0, r.Skipped("missing"))
+
+		p, _ = New()
+		p.Add(config.Config{
+			Name:            "bold",
+			Activators:      []string{"<b>"},
+			Deactivator:     "</b>",
+			CaptureLimit:    8,
+			CaptureOverflow: "error",
+		})
+		p.Add(config.Config{
+			Activators:  []string{"<b>"},
+			Deactivator: "</b>",
+		})
+		err := p.PluckString(input, stream)
+		assert.Equal(t, &CaptureOverflowError{Name: "bold", Limit: 8, Size: 20}, err)
+		assert.Equal(t, "plucker bold: capture of 20 bytes exceeds the capture limit of 8 bytes", err.Error())
+		assert.Equal(t, `{"1":["short","this one is too long","end"],"bold":"short"}`, p.ResultJSON())
+	}
+}
+
+func TestCaptureOverflowConfig(t *testing.T) {
+	p, _ := New()
+	err := p.Add(config.Config{
+		Activators:      []string{"<b>"},
+		Deactivator:     "</b>",
+		CaptureLimit:    8,
+		CaptureOverflow: "drop",
+	})
+	assert.Equal(t, "problem adding plucker 0: unknown capture overflow mode 'drop'", err.Error())
+
+	err = p.Add(config.Config{
+		Activators:   []string{"<b>"},
+		Deactivator:  "</b>",
+		CaptureLimit: -1,
+	})
+	assert.Equal(t, "problem adding plucker 0: negative capture limit -1", err.Error())
+}
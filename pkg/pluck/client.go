@@ -0,0 +1,240 @@
+package pluck
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"time"
+
+	// external
+	"github.com/pkg/errors"
+)
+
+// defaultUserAgent is sent by the URL functions unless
+// another User-Agent header is given.
+const defaultUserAgent = "Mozilla/5.0 (X11; Linux x86_64; rv:52.0) Gecko/20100101 Firefox/52.0"
+
+// Option configures a Plucker created by New. The options
+// set how the URL functions download the pages.
+type Option func(*httpOptions) error
+
+// httpOptions holds the options of the HTTP client and requests.
+type httpOptions struct {
+	client    *http.Client
+	transport http.RoundTripper
+	header    http.Header
+	cookies   []*http.Cookie
+	jar       http.CookieJar
+	username  string
+	password  string
+	basicAuth bool
+	token     string
+	timeout   time.Duration
+	proxy     *url.URL
+	tlsConfig *tls.Config
+	retries   int
+	backoff   time.Duration
+}
+
+// WithHTTPClient makes the URL functions use a copy of the given client,
+// which the other options then modify.
+func WithHTTPClient(client *http.Client) Option {
+	return func(o *httpOptions) error {
+		if client == nil {
+			return errors.New("nil HTTP client")
+		}
+		o.client = client
+		return nil
+	}
+}
+
+// WithTransport makes the HTTP client send the requests with the
+// given round tripper, e.g. to record them or to stub a server.
+func WithTransport(transport http.RoundTripper) Option {
+	return func(o *httpOptions) error {
+		o.transport = transport
+		return nil
+	}
+}
+
+// WithHeader adds a header to the requests. It can be given several
+// times, and replaces the default User-Agent header.
+func WithHeader(key, value string) Option {
+	return func(o *httpOptions) error {
+		o.header.Add(key, value)
+		return nil
+	}
+}
+
+// WithCookie adds a cookie to the requests.
+func WithCookie(cookie *http.Cookie) Option {
+	return func(o *httpOptions) error {
+		o.cookies = append(o.cookies, cookie)
+		return nil
+	}
+}
+
+// WithCookieJar makes the HTTP client keep the cookies set by the
+// servers in the given jar, and send them back.
+func WithCookieJar(jar http.CookieJar) Option {
+	return func(o *httpOptions) error {
+		o.jar = jar
+		return nil
+	}
+}
+
+// WithBasicAuth authenticates the requests with
+// the given user name and password.
+func WithBasicAuth(username, password string) Option {
+	return func(o *httpOptions) error {
+		o.username, o.password, o.basicAuth = username, password, true
+		return nil
+	}
+}
+
+// WithBearerToken authenticates the requests with the given token.
+func WithBearerToken(token string) Option {
+	return func(o *httpOptions) error {
+		o.token = token
+		return nil
+	}
+}
+
+// WithTimeout limits the time taken by each request, reading
+// the page included. There is no limit by default.
+func WithTimeout(timeout time.Duration) Option {
+	return func(o *httpOptions) error {
+		if timeout < 0 {
+			return errors.Errorf("negative timeout %s", timeout)
+		}
+		o.timeout = timeout
+		return nil
+	}
+}
+
+// WithProxy sends the requests through the proxy at the given URL,
+// instead of the one set by the environment.
+func WithProxy(proxy string) Option {
+	return func(o *httpOptions) error {
+		u, err := url.Parse(proxy)
+		if err != nil {
+			return errors.Wrap(err, "invalid proxy URL")
+		}
+		o.proxy = u
+		return nil
+	}
+}
+
+// WithTLSConfig sets the TLS configuration of the HTTP client,
+// e.g. to trust other certificate authorities.
+func WithTLSConfig(config *tls.Config) Option {
+	return func(o *httpOptions) error {
+		o.tlsConfig = config
+		return nil
+	}
+}
+
+// WithRetry retries a request up to the given number of times when it
+// fails or the server answers with a 5xx status, waiting for backoff
+// before the first retry and twice as long before each next one.
+func WithRetry(retries int, backoff time.Duration) Option {
+	return func(o *httpOptions) error {
+		if retries < 0 || backoff < 0 {
+			return errors.Errorf("invalid retries %d with backoff %s", retries, backoff)
+		}
+		o.retries, o.backoff = retries, backoff
+		return nil
+	}
+}
+
+// newClient returns the HTTP client set by the options.
+func (o *httpOptions) newClient() (*http.Client, error) {
+	client := &http.Client{}
+	if o.client != nil {
+		c := *o.client
+		client = &c
+	}
+	if o.transport != nil {
+		client.Transport = o.transport
+	}
+	if o.proxy != nil || o.tlsConfig != nil {
+		var transport *http.Transport
+		switch t := client.Transport.(type) {
+		case nil:
+			transport = http.DefaultTransport.(*http.Transport).Clone()
+		case *http.Transport:
+			transport = t.Clone()
+		default:
+			return nil, errors.Errorf("cannot set the proxy or the TLS configuration of a %T transport", t)
+		}
+		if o.proxy != nil {
+			transport.Proxy = http.ProxyURL(o.proxy)
+		}
+		if o.tlsConfig != nil {
+			transport.TLSClientConfig = o.tlsConfig
+		}
+		client.Transport = transport
+	}
+	if o.timeout > 0 {
+		client.Timeout = o.timeout
+	}
+	if o.jar != nil {
+		client.Jar = o.jar
+	}
+	return client, nil
+}
+
+// newRequest returns a GET request of the URL set by the options.
+func (o *httpOptions) newRequest(ctx context.Context, url string) (*http.Request, error) {
+	request, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return nil, err
+	}
+	request = request.WithContext(ctx)
+	for key, values := range o.header {
+		request.Header[key] = append([]string(nil), values...)
+	}
+	if request.Header.Get("User-Agent") == "" {
+		request.Header.Set("User-Agent", defaultUserAgent)
+	}
+	for _, cookie := range o.cookies {
+		request.AddCookie(cookie)
+	}
+	if o.basicAuth {
+		request.SetBasicAuth(o.username, o.password)
+	}
+	if o.token != "" {
+		request.Header.Set("Authorization", "Bearer "+o.token)
+	}
+	return request, nil
+}
+
+// get downloads a page, retrying as set by the options.
+func (p *Plucker) get(ctx context.Context, url string) (*http.Response, error) {
+	client := p.client
+	if client == nil {
+		client = &http.Client{}
+	}
+	for retry := 0; ; retry++ {
+		request, err := p.http.newRequest(ctx, url)
+		if err != nil {
+			return nil, err
+		}
+		resp, err := client.Do(request)
+		if retry == p.http.retries || ctx.Err() != nil || (err == nil && resp.StatusCode < 500) {
+			return resp, err
+		}
+		if err == nil {
+			io.Copy(ioutil.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		select {
+		case <-time.After(p.http.backoff << uint(retry)):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	}
+}
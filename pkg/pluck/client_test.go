@@ -0,0 +1,225 @@
+package pluck
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"net/http"
+	"net/http/cookiejar"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+// newEchoPlucker returns a plucker of the <b> tags,
+// created with the given options.
+func newEchoPlucker(t *testing.T, options ...Option) *Plucker {
+	p, err := New(options...)
+	if !assert.Nil(t, err) {
+		t.FailNow()
+	}
+	p.Add(config.Config{Activators: []string{"<b>"}, Deactivator: "</b>", Limit: -1})
+	return p
+}
+
+func TestClientRequests(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, password, _ := r.BasicAuth()
+		cookie, _ := r.Cookie("session")
+		var value string
+		if cookie != nil {
+			value = cookie.Value
+		}
+		for _, s := range []string{r.UserAgent(), strings.Join(r.Header["X-Test"], ","), value, user + ":" + password} {
+			w.Write([]byte("<b>" + s + "</b>"))
+		}
+	}))
+	defer ts.Close()
+
+	p := newEchoPlucker(t)
+	assert.Nil(t, p.PluckURL(ts.URL))
+	assert.Equal(t, []string{defaultUserAgent, "", "", ":"}, p.TypedResult().Strings("0"))
+
+	p = newEchoPlucker(t,
+		WithHeader("User-Agent", "pluck"),
+		WithHeader("X-Test", "a"),
+		WithHeader("X-Test", "b"),
+		WithCookie(&http.Cookie{Name: "session", Value: "42"}),
+		WithBasicAuth("bob", "secret"),
+	)
+	assert.Nil(t, p.PluckURL(ts.URL))
+	assert.Equal(t, []string{"pluck", "a,b", "42", "bob:secret"}, p.TypedResult().Strings("0"))
+
+	// the requests are the same each time
+	assert.Nil(t, p.PluckURL(ts.URL))
+	assert.Equal(t, []string{"pluck", "a,b", "42", "bob:secret"}, p.TypedResult().Strings("0"))
+}
+
+func TestClientBearerToken(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<b>" + r.Header.Get("Authorization") + "</b>"))
+	}))
+	defer ts.Close()
+
+	p := newEchoPlucker(t, WithBearerToken("t0k3n"))
+	assert.Nil(t, p.PluckURL(ts.URL))
+	assert.Equal(t, `{"0":"Bearer t0k3n"}`, p.ResultJSON())
+}
+
+func TestClientCookieJar(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if cookie, err := r.Cookie("visited"); err == nil {
+			w.Write([]byte("<b>" + cookie.Value + "</b>"))
+			return
+		}
+		http.SetCookie(w, &http.Cookie{Name: "visited", Value: "yes"})
+		w.Write([]byte("<b>first</b>"))
+	}))
+	defer ts.Close()
+
+	jar, _ := cookiejar.New(nil)
+	p := newEchoPlucker(t, WithCookieJar(jar))
+	assert.Nil(t, p.PluckURL(ts.URL))
+	assert.Equal(t, `{"0":"first"}`, p.ResultJSON())
+	assert.Nil(t, p.PluckURL(ts.URL))
+	assert.Equal(t, `{"0":"yes"}`, p.ResultJSON())
+}
+
+func TestClientTimeout(t *testing.T) {
+	done := make(chan struct{})
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	defer close(done)
+
+	p := newEchoPlucker(t, WithTimeout(50*time.Millisecond))
+	start := time.Now()
+	assert.NotNil(t, p.PluckURL(ts.URL))
+	assert.True(t, time.Since(start) < 5*time.Second)
+}
+
+func TestClientRetry(t *testing.T) {
+	var calls int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if atomic.AddInt32(&calls, 1) <= 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			w.Write([]byte("<b>unavailable</b>"))
+			return
+		}
+		w.Write([]byte("<b>ok</b>"))
+	}))
+	defer ts.Close()
+
+	p := newEchoPlucker(t, WithRetry(3, time.Millisecond))
+	assert.Nil(t, p.PluckURL(ts.URL))
+	assert.Equal(t, `{"0":"ok"}`, p.ResultJSON())
+	assert.Equal(t, int32(3), atomic.LoadInt32(&calls))
+
+	// the last answer is plucked once the retries are exhausted
+	atomic.StoreInt32(&calls, 0)
+	p = newEchoPlucker(t, WithRetry(1, time.Millisecond))
+	assert.Nil(t, p.PluckURL(ts.URL))
+	assert.Equal(t, `{"0":"unavailable"}`, p.ResultJSON())
+	assert.Equal(t, int32(2), atomic.LoadInt32(&calls))
+
+	// no retry by default
+	atomic.StoreInt32(&calls, 0)
+	p = newEchoPlucker(t)
+	assert.Nil(t, p.PluckURL(ts.URL))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+
+	// the backoff stops with the context
+	atomic.StoreInt32(&calls, 0)
+	p = newEchoPlucker(t, WithRetry(3, time.Hour))
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	assert.Equal(t, context.DeadlineExceeded, p.PluckURLContext(ctx, ts.URL))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&calls))
+}
+
+// countTransport counts the requests sent by the default transport.
+type countTransport struct {
+	n int32
+}
+
+func (c *countTransport) RoundTrip(r *http.Request) (*http.Response, error) {
+	atomic.AddInt32(&c.n, 1)
+	return http.DefaultTransport.RoundTrip(r)
+}
+
+func TestClientTransport(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<b>a</b>"))
+	}))
+	defer ts.Close()
+
+	transport := &countTransport{}
+	p := newEchoPlucker(t, WithTransport(transport))
+	assert.Nil(t, p.PluckURL(ts.URL))
+	assert.Equal(t, int32(1), atomic.LoadInt32(&transport.n))
+
+	// the proxy and the TLS configuration need an *http.Transport
+	_, err := New(WithTransport(transport), WithProxy("http://localhost:3128"))
+	assert.EqualError(t, err, "problem creating plucker: cannot set the proxy or the TLS configuration of a *pluck.countTransport transport")
+}
+
+func TestClientTLS(t *testing.T) {
+	ts := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<b>secure</b>"))
+	}))
+	defer ts.Close()
+
+	// the certificate of the test server is not trusted by default
+	p := newEchoPlucker(t)
+	assert.NotNil(t, p.PluckURL(ts.URL))
+
+	pool := x509.NewCertPool()
+	pool.AddCert(ts.Certificate())
+	p = newEchoPlucker(t, WithTLSConfig(&tls.Config{RootCAs: pool}))
+	assert.Nil(t, p.PluckURL(ts.URL))
+	assert.Equal(t, `{"0":"secure"}`, p.ResultJSON())
+
+	p = newEchoPlucker(t, WithHTTPClient(ts.Client()), WithHeader("X-Test", "a"))
+	assert.Nil(t, p.PluckURL(ts.URL))
+	assert.Equal(t, `{"0":"secure"}`, p.ResultJSON())
+}
+
+func TestClientProxy(t *testing.T) {
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// a proxy gets the absolute URL of the page
+		w.Write([]byte("<b>" + r.URL.String() + "</b>"))
+	}))
+	defer proxy.Close()
+
+	p := newEchoPlucker(t, WithProxy(proxy.URL))
+	assert.Nil(t, p.PluckURL("http://example.com/page"))
+	assert.Equal(t, `{"0":"http://example.com/page"}`, p.ResultJSON())
+}
+
+func TestClientOptionErrors(t *testing.T) {
+	for _, test := range []struct {
+		option Option
+		err    string
+	}{
+		{WithHTTPClient(nil), "problem creating plucker: nil HTTP client"},
+		{WithTimeout(-time.Second), "problem creating plucker: negative timeout -1s"},
+		{WithRetry(-1, 0), "problem creating plucker: invalid retries -1 with backoff 0s"},
+		{WithProxy(":"), "problem creating plucker: invalid proxy URL: "},
+	} {
+		p, err := New(test.option)
+		assert.Nil(t, p)
+		if assert.NotNil(t, err) {
+			assert.True(t, strings.HasPrefix(err.Error(), test.err), err.Error())
+		}
+	}
+}
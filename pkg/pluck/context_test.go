@@ -0,0 +1,98 @@
+package pluck
+
+import (
+	"bufio"
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+// cancelReader cancels its context after n bytes and then never ends.
+type cancelReader struct {
+	n      int
+	cancel context.CancelFunc
+}
+
+func (r *cancelReader) Read(b []byte) (int, error) {
+	if r.n <= 0 {
+		r.cancel()
+		for i := range b {
+			b[i] = 'x'
+		}
+		return len(b), nil
+	}
+	s := strings.Repeat("<b>a</b>", 1+r.n/8)
+	n := copy(b, s[:r.n])
+	r.n -= n
+	return n, nil
+}
+
+func TestPluckContextCanceled(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		p.Add(config.Config{Activators: []string{"<b>"}, Deactivator: "</b>"})
+		ctx, cancel := context.WithCancel(context.Background())
+		err := p.PluckContext(ctx, bufio.NewReader(&cancelReader{n: 16, cancel: cancel}), stream)
+		assert.Equal(t, context.Canceled, err, "stream %v", stream)
+	}
+
+	// the captures made before the cancellation are kept
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		p.Add(config.Config{Activators: []string{"<b>"}, Deactivator: "</b>"})
+		ctx, cancel := context.WithCancel(context.Background())
+		r := io.MultiReader(strings.NewReader("<b>a</b><b>b</b><b>c"), &cancelReader{cancel: cancel})
+		err := p.PluckContext(ctx, bufio.NewReader(r), stream)
+		assert.Equal(t, context.Canceled, err, "stream %v", stream)
+		assert.Equal(t, `{"0":["a","b"]}`, p.ResultJSON(), "stream %v", stream)
+	}
+}
+
+func TestPluckContextDone(t *testing.T) {
+	p, _ := New()
+	p.Add(config.Config{Activators: []string{"<b>"}, Deactivator: "</b>"})
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	result, err := p.ExtractContext(ctx, strings.NewReader("<b>a</b>"))
+	assert.Equal(t, context.Canceled, err)
+	assert.Equal(t, map[string]interface{}{"0": ""}, result.Map())
+
+	result, err = p.ExtractContext(context.Background(), strings.NewReader("<b>a</b>"))
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]interface{}{"0": "a"}, result.Map())
+}
+
+func TestPluckURLContextDeadline(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte("<b>a</b>"))
+		w.(http.Flusher).Flush()
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		p.Add(config.Config{Activators: []string{"<b>"}, Deactivator: "</b>"})
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		start := time.Now()
+		err := p.PluckURLContext(ctx, ts.URL, stream)
+		assert.NotNil(t, err)
+		assert.Equal(t, context.DeadlineExceeded, ctx.Err())
+		assert.True(t, time.Since(start) < 5*time.Second)
+		assert.Equal(t, `{"0":"a"}`, p.ResultJSON(), "stream %v", stream)
+		cancel()
+	}
+}
@@ -0,0 +1,162 @@
+package pluck
+
+import (
+	"bytes"
+
+	// external
+	log "github.com/sirupsen/logrus"
+)
+
+// chunkSize is the number of bytes fed at once to the engine.
+const chunkSize = 64 << 10
+
+// feedChunk feeds a chunk of the input, found at the given position,
+// to the matching engine until the plucker finishes. It is the only
+// matching engine, driven by both the parallel and the streaming
+// front-ends so that they give the same results. If emit is not nil,
+// the captures are passed to it instead of being stored, and the first
+// error it returns is returned.
+func (s *unitState) feedChunk(chunk []byte, start position, emit func(Capture) error) error {
+	s.chunk, s.chunkStart = chunk, start
+	s.cursor, s.cursorK = start, 0
+	for s.k = 0; s.k < len(chunk) && !s.isFinished; s.k++ {
+		if s.k += s.fastForward(); s.k == len(chunk) {
+			break
+		}
+		if err := s.feed(chunk[s.k], emit); err != nil {
+			return err
+		}
+	}
+	s.chunk = nil
+	return nil
+}
+
+// fastForward skips the bytes following the current one which cannot
+// be part of a match: they are looked for with bytes.IndexByte rather
+// than fed one at a time. It returns the number of bytes skipped.
+func (s *unitState) fastForward() int {
+	if s.watchFinisher() {
+		// the finisher must see every byte
+		return 0
+	}
+	rest := s.chunk[s.k:]
+	if s.numActivated < len(s.activators) {
+		return skipLiteral(s.activators[s.numActivated], rest)
+	}
+	n := skipLiteral(s.deactivator, rest)
+	if n > 0 {
+		s.appendCapture(rest[:n], s.k)
+	}
+	return n
+}
+
+// skipLiteral returns the number of leading bytes of b which leave the
+// matcher unchanged, when it is a literal matcher which has not matched
+// anything yet: these bytes cannot start a match.
+func skipLiteral(m matcher, b []byte) int {
+	lm, ok := m.(*literalMatcher)
+	if !ok || lm.i > 0 || len(lm.pattern) == 0 {
+		return 0
+	}
+	if n := bytes.IndexByte(b, lm.pattern[0]); n >= 0 {
+		return n
+	}
+	return len(b)
+}
+
+// feed processes the current byte of the chunk.
+func (s *unitState) feed(b byte, emit func(Capture) error) (err error) {
+	u := s.unit
+	if s.numActivated < len(s.activators) {
+		// look for activators
+		if s.activators[s.numActivated].feed(b) > 0 {
+			log.Info(string(b), "Activated")
+			s.numActivated++
+		}
+	} else {
+		// add to capture
+		s.appendCapture(s.chunk[s.k:s.k+1], s.k)
+		// look for deactivators
+		if n := s.deactivator.feed(b); n > 0 {
+			log.Info(string(b), "Deactivated")
+			// add capture
+			err = s.endCapture(n, emit)
+			// reset
+			s.numActivated = u.permanent
+		}
+	}
+
+	// look for finisher
+	if s.watchFinisher() {
+		if n := s.finisher.feed(b); n > 0 {
+			log.Info(string(b), "Finished")
+			if err == nil && u.record {
+				// the current record ends before the finisher
+				err = s.flush(n, emit)
+			}
+			s.isFinished = true
+		}
+	}
+
+	if s.numCaptured == u.config.Limit {
+		s.isFinished = true
+	}
+	return
+}
+
+// watchFinisher reports whether the finisher must be looked for: once
+// something was captured, or once the first record started, right from
+// its first byte.
+func (s *unitState) watchFinisher() bool {
+	return s.finisher != nil && (s.numCaptured > 0 || s.unit.record && s.numActivated == len(s.activators))
+}
+
+// endCapture finishes the current capture, whose last n bytes are the
+// deactivator, and adds it unless it is longer than the maximum.
+func (s *unitState) endCapture(n int, emit func(Capture) error) (err error) {
+	if sp, ok := s.takeCapture(n); ok {
+		log.Info(string(sp.text))
+		sp = s.unit.clean(sp)
+		if s.unit.maximum < 1 || len(sp.text) < s.unit.maximum {
+			err = s.addCapture(sp, emit)
+		}
+	}
+	return
+}
+
+// flush ends the current record, if any, whose last n bytes are not
+// part of it.
+func (s *unitState) flush(n int, emit func(Capture) error) error {
+	if !s.unit.record || s.numActivated < len(s.activators) || s.captureN == 0 {
+		return nil
+	}
+	if n >= s.captureN {
+		// the record is all finisher, which may even start in a previous
+		// record: that one was emitted already, when the record was found
+		s.captureByte = s.captureByte[:0]
+		s.captureN = 0
+		return nil
+	}
+	return s.endCapture(n, emit)
+}
+
+// finish is called at the end of the input, which ends the current record.
+func (s *unitState) finish(emit func(Capture) error) error {
+	if s.isFinished {
+		return nil
+	}
+	return s.flush(0, emit)
+}
+
+// positionAt returns the position of the byte at the index k of the
+// chunk, which must not precede the last position asked for.
+func (s *unitState) positionAt(k int) position {
+	s.cursor = s.cursor.skip(s.chunk[s.cursorK:k])
+	s.cursorK = k
+	return s.cursor
+}
+
+// offset returns the offset in the input of the byte being fed.
+func (s *unitState) offset() int {
+	return s.chunkStart.offset + s.k
+}
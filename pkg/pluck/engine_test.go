@@ -0,0 +1,169 @@
+package pluck
+
+import (
+	"bytes"
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/iotest"
+
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+// differentialConfigs are pluckers using every feature of the engine.
+var differentialConfigs = []config.Config{
+	{Name: "title", Activators: []string{"<title>"}, Deactivator: "</title>"},
+	{Name: "maximum", Activators: []string{"<"}, Deactivator: ">", Maximum: 6},
+	{Name: "finisher", Activators: []string{"<"}, Deactivator: ">", Finisher: "</head>"},
+	{Name: "early finisher", Activators: []string{"a"}, Deactivator: "e", Finisher: "<"},
+	{Name: "limit", Activators: []string{"<a", ">"}, Deactivator: "<", Limit: 5},
+	{Name: "permanent", Activators: []string{"<ul", "<li>"}, Deactivator: "</li>", Permanent: 1},
+	{Name: "sanitize", Activators: []string{"<p"}, Deactivator: "</p>", Sanitize: true},
+	{Name: "regex", Activators: []string{`<h\d[^>]*>`}, Deactivator: `</h\d>`, Regex: true},
+	{Name: "split", Activators: []string{"class=\""}, Deactivator: "\"", Match: config.Match{Separator: " ", Split: true}},
+	{Name: "capture limit", Activators: []string{"<"}, Deactivator: ">", CaptureLimit: 8, CaptureOverflow: "skip"},
+	{Name: "lines", Activators: []string{"\n"}, Deactivator: "\n", Permanent: 1, Limit: 20},
+}
+
+// TestPluckStreamDifferential checks that plucking in parallel and
+// streaming give the same results on every file of the tests directory.
+func TestPluckStreamDifferential(t *testing.T) {
+	files, err := filepath.Glob("../../tests/*")
+	assert.Nil(t, err)
+	configs, err := filepath.Glob("../../tests/*.toml")
+	assert.Nil(t, err)
+
+	var pluckers []*Plucker
+	for _, c := range configs {
+		p, _ := New()
+		assert.Nil(t, p.Load(c), c)
+		pluckers = append(pluckers, p)
+	}
+	p, _ := New()
+	for _, c := range differentialConfigs {
+		assert.Nil(t, p.Add(c), c.Name)
+	}
+	pluckers = append(pluckers, p)
+
+	for _, f := range files {
+		for _, p := range pluckers {
+			parallel, errParallel := p.ExtractFile(f)
+			stream, errStream := p.ExtractFile(f, true)
+			assert.Equal(t, errParallel, errStream, f)
+			for _, name := range parallel.Names() {
+				assert.Equal(t, parallel.Get(name), stream.Get(name), "file %s, plucker %s", f, name)
+			}
+			assert.Equal(t, parallel.Map(), stream.Map(), f)
+
+			// the chunks read do not change the result
+			data, _ := ioutil.ReadFile(f)
+			bytewise, err := p.Extract(iotest.OneByteReader(bytes.NewReader(data)), true)
+			assert.Equal(t, errStream, err, f)
+			for _, name := range stream.Names() {
+				assert.Equal(t, stream.Get(name), bytewise.Get(name), "file %s, plucker %s, one byte at a time", f, name)
+			}
+
+			// emitted captures are the streamed ones, in input order
+			emitted := make(map[string][]Capture)
+			p.PluckStreamFunc(bytes.NewReader(data), func(name string, capture []byte) error {
+				emitted[name] = append(emitted[name], Capture{Text: string(capture)})
+				return nil
+			})
+			for _, name := range stream.Names() {
+				assert.Equal(t, stream.Strings(name), texts(emitted[name]), "file %s, plucker %s", f, name)
+			}
+		}
+	}
+}
+
+func texts(captures []Capture) []string {
+	s := make([]string, len(captures))
+	for i, c := range captures {
+		s[i] = c.Text
+	}
+	return s
+}
+
+const recordHTML = `<div class="item"><b>Apple</b> <span class="price">1.20</span></div>
+<div class="item"><b>Cherry</b></div>
+<div class="item"><b>Banana</b> <span class="price">0.50</span> <span class="price">0.45</span></div>
+<p>Similar items</p>
+<div class="item"><b>Orange</b> <span class="price">0.80</span></div>`
+
+func TestRecords(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		err := p.Add(config.Config{
+			Name:   "items",
+			Record: `<div class="item">`,
+			Children: []config.Config{
+				{Name: "name", Activators: []string{"<b>"}, Deactivator: "</b>"},
+				{Name: "price", Activators: []string{`<span class="price">`}, Deactivator: "<"},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, p.PluckString(recordHTML, stream))
+		assert.Equal(t, `{"items":[{"name":"Apple","price":"1.20"},{"name":"Cherry","price":null},{"name":"Banana","price":["0.50","0.45"]},{"name":"Orange","price":"0.80"}]}`, p.ResultJSON(), "stream %v", stream)
+
+		// the finisher ends the last record
+		p, _ = New()
+		p.Add(config.Config{
+			Name:     "items",
+			Record:   `<div class="item">`,
+			Finisher: "<p>Similar",
+			Children: []config.Config{{Name: "name", Activators: []string{"<b>"}, Deactivator: "</b>"}},
+		})
+		p.PluckString(recordHTML, stream)
+		assert.Equal(t, `{"items":[{"name":"Apple"},{"name":"Cherry"},{"name":"Banana"}]}`, p.ResultJSON(), "stream %v", stream)
+		records := p.TypedResult().Get("items")
+		assert.Equal(t, "<b>Banana</b> <span class=\"price\">0.50</span> <span class=\"price\">0.45</span></div>", records[2].Text)
+
+		// the limit counts the records
+		p, _ = New()
+		p.Add(config.Config{Name: "items", Record: `<div class="item">`, Limit: 2, Sanitize: true})
+		p.PluckString(recordHTML, stream)
+		assert.Equal(t, `{"items":["Apple 1.20","Cherry"]}`, p.ResultJSON(), "stream %v", stream)
+	}
+}
+
+func TestRecordsEmitted(t *testing.T) {
+	p, _ := New()
+	p.Add(config.Config{Name: "items", Record: `<div class="item">`, Sanitize: true})
+	p.Add(config.Config{Name: "similar", Activators: []string{"<p>"}, Deactivator: "</p>"})
+	var got []string
+	p.PluckStreamFunc(strings.NewReader(recordHTML), func(name string, capture []byte) error {
+		got = append(got, name+":"+string(capture))
+		return nil
+	})
+	// the last record is emitted at the end of the input
+	assert.Equal(t, []string{"items:Apple 1.20", "items:Cherry", "similar:Similar items", "items:Banana 0.50 0.45\nSimilar items", "items:Orange 0.80"}, got)
+}
+
+func TestRecordsInvalid(t *testing.T) {
+	p, _ := New()
+	err := p.Add(config.Config{Name: "items", Record: "<li>", Deactivator: "</li>"})
+	assert.Equal(t, "problem adding plucker items: a record cannot have activators or a deactivator", err.Error())
+}
+
+func TestRecordsFinisher(t *testing.T) {
+	for _, test := range []struct {
+		record, finisher, input string
+		expected                string
+	}{
+		// the finisher starts in a previous record
+		{"<li", `<li class="more">`, `<ul><li>a</li><li>b</li><li class="more">x</li></ul>`, `{"items":["\u003ea\u003c/li\u003e","\u003eb\u003c/li\u003e"]}`},
+		// the finisher starts with a record
+		{"<li>", "stop", "<li>stop<li>x", ""},
+		{"<li>", "stop", "<li>a<li>stop<li>x", `{"items":"a"}`},
+	} {
+		for _, stream := range []bool{false, true} {
+			p, _ := New()
+			p.Add(config.Config{Name: "items", Record: test.record, Finisher: test.finisher})
+			assert.Nil(t, p.PluckString(test.input, stream))
+			assert.Equal(t, test.expected, p.ResultJSON(), "%s, stream %v", test.input, stream)
+		}
+	}
+}
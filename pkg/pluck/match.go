@@ -1 +1,102 @@
 package pluck
+
+import (
+	"bytes"
+	"unicode"
+
+	// external
+	"github.com/pkg/errors"
+
+	// internal
+	config "github.com/sniperkit/pluck/pkg/config"
+	query "github.com/sniperkit/pluck/pkg/query"
+)
+
+// compileMatch checks the matching parameters of a plucker
+// and fills the corresponding fields of the unit.
+func (u *pluckUnit) compileMatch(c config.Config) (err error) {
+	u.matchMode = config.MatchMode(c.Match.Mode)
+	if u.matchMode == "" {
+		u.matchMode = config.MATCH_ANY
+	}
+	switch u.matchMode {
+	case config.MATCH_ALL, config.MATCH_ANY, config.MATCH_PHRASE:
+	case config.MATCH_BOOLEAN:
+		if u.query, err = query.Parse(c.Match.Phrase); err != nil {
+			return errors.Wrap(err, "invalid boolean query")
+		}
+	default:
+		return errors.Errorf("unknown match mode '%s'", c.Match.Mode)
+	}
+	u.matchPhrase = []byte(c.Match.Phrase)
+	u.separator = []byte(c.Match.Separator)
+	u.autoSplit = c.Match.Split && len(u.separator) > 0
+	return
+}
+
+// filter splits a capture with the separator (if splitting is enabled)
+// and returns the pieces that satisfy the blacklist, the whitelist
+// and the matching mode of the unit.
+func (u *pluckUnit) filter(sp span) (kept []span) {
+	if !u.autoSplit {
+		if u.keep(sp.text) {
+			kept = append(kept, sp)
+		}
+		return
+	}
+	start := sp.start
+	for _, field := range bytes.Split(sp.text, u.separator) {
+		piece := sp
+		piece.text = bytes.TrimSpace(field)
+		if sp.mapped {
+			leading := len(field) - len(bytes.TrimLeftFunc(field, unicode.IsSpace))
+			piece.start = start.skip(field[:leading])
+			piece.length = len(piece.text)
+			start = start.skip(field).skip(u.separator)
+		}
+		if len(piece.text) > 0 && u.keep(piece.text) {
+			kept = append(kept, piece)
+		}
+	}
+	return
+}
+
+// keep reports whether a plucked occurrence should be kept.
+func (u *pluckUnit) keep(b []byte) bool {
+	// `blacklist` excludes an occurrence containing any of its words
+	if containsAny(b, u.blacklist) {
+		return false
+	}
+	// `whitelist` requires an occurrence to contain one of its words
+	if len(u.whitelist) > 0 && !containsAny(b, u.whitelist) {
+		return false
+	}
+	switch u.matchMode {
+	case config.MATCH_ALL:
+		return containsAll(b, u.patterns)
+	case config.MATCH_PHRASE:
+		return len(u.matchPhrase) == 0 || bytes.Contains(b, u.matchPhrase)
+	case config.MATCH_BOOLEAN:
+		return u.query.Match(b)
+	default:
+		return len(u.patterns) == 0 || containsAny(b, u.patterns)
+	}
+}
+
+func containsAny(b []byte, words [][]byte) bool {
+	for _, w := range words {
+		if bytes.Contains(b, w) {
+			return true
+		}
+	}
+	return false
+}
+
+func containsAll(b []byte, words [][]byte) bool {
+	for _, w := range words {
+		if !bytes.Contains(b, w) {
+			return false
+		}
+	}
+	return true
+}
@@ -1 +1,138 @@
 package pluck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+const matchHTML = `<ul>
+<li>red apple</li>
+<li>green apple</li>
+<li>red cherry</li>
+<li>yellow banana</li>
+</ul>`
+
+func TestMatchModes(t *testing.T) {
+	for _, tc := range []struct {
+		match    config.Match
+		patterns []string
+		expected string
+	}{
+		{config.Match{}, nil, `{"0":["red apple","green apple","red cherry","yellow banana"]}`},
+		{config.Match{Mode: "any"}, []string{"cherry", "banana"}, `{"0":["red cherry","yellow banana"]}`},
+		{config.Match{Mode: "all"}, []string{"red", "apple"}, `{"0":"red apple"}`},
+		{config.Match{Mode: "phrase", Phrase: "en ap"}, nil, `{"0":"green apple"}`},
+	} {
+		p, _ := New()
+		err := p.Add(config.Config{
+			Activators:  []string{"<li>"},
+			Deactivator: "<",
+			Match:       tc.match,
+			Patterns:    tc.patterns,
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, p.PluckString(matchHTML))
+		assert.Equal(t, tc.expected, p.ResultJSON(), "mode %q", tc.match.Mode)
+	}
+}
+
+func TestMatchWhitelistBlacklist(t *testing.T) {
+	p, _ := New()
+	p.Add(config.Config{
+		Activators:  []string{"<li>"},
+		Deactivator: "<",
+		Whitelist:   []string{"red", "yellow"},
+		Blacklist:   []string{"cherry"},
+	})
+	p.PluckString(matchHTML)
+	assert.Equal(t, `{"0":["red apple","yellow banana"]}`, p.ResultJSON())
+
+	p, _ = New()
+	p.Add(config.Config{
+		Activators:  []string{"<li>"},
+		Deactivator: "<",
+		Blacklist:   []string{"apple"},
+	})
+	p.PluckString(matchHTML, true)
+	assert.Equal(t, `{"0":["red cherry","yellow banana"]}`, p.ResultJSON())
+}
+
+func TestMatchSplit(t *testing.T) {
+	p, _ := New()
+	p.Add(config.Config{
+		Activators:  []string{"tags:"},
+		Deactivator: "\n",
+		Limit:       3,
+		Match:       config.Match{Separator: ",", Split: true},
+		Blacklist:   []string{"draft"},
+	})
+	p.PluckString("tags: go, draft,, text , pluck, parser\n")
+	assert.Equal(t, `{"0":["go","text","pluck"]}`, p.ResultJSON())
+
+	// without split, the separator is ignored
+	p, _ = New()
+	p.Add(config.Config{
+		Activators:  []string{"tags:"},
+		Deactivator: "\n",
+		Match:       config.Match{Separator: ","},
+	})
+	p.PluckString("tags: go, pluck\n")
+	assert.Equal(t, `{"0":"go, pluck"}`, p.ResultJSON())
+}
+
+func TestMatchUnknownMode(t *testing.T) {
+	p, _ := New()
+	err := p.Add(config.Config{
+		Activators:  []string{"<li>"},
+		Deactivator: "<",
+		Match:       config.Match{Mode: "some"},
+	})
+	assert.NotNil(t, err)
+	assert.Equal(t, 0, len(p.pluckers))
+}
+
+func TestMatchLoad(t *testing.T) {
+	p, _ := New()
+	err := p.LoadFromString(`
+[[pluck]]
+activators = ["<li>"]
+deactivator = "<"
+patterns = ["red", "apple"]
+blacklist = ["green"]
+
+[pluck.match]
+mode = "all"
+`)
+	assert.Nil(t, err)
+	p.PluckString(matchHTML)
+	assert.Equal(t, `{"0":"red apple"}`, p.ResultJSON())
+}
+
+func TestMatchBoolean(t *testing.T) {
+	p, _ := New()
+	err := p.Add(config.Config{
+		Activators:  []string{"<li>"},
+		Deactivator: "<",
+		Match:       config.Match{Mode: "boolean", Phrase: "(apple | cherry) -green"},
+	})
+	assert.Nil(t, err)
+	p.PluckString(matchHTML)
+	assert.Equal(t, `{"0":["red apple","red cherry"]}`, p.ResultJSON())
+
+	p, _ = New()
+	err = p.LoadFromString(`
+[[pluck]]
+name = "fruits"
+activators = ["<li>"]
+deactivator = "<"
+
+[pluck.match]
+mode = "boolean"
+phrase = "(apple | cherry"
+`)
+	assert.Equal(t, `line 2: problem adding plucker fruits: invalid boolean query: query "(apple | cherry": missing ')' for '(' at offset 0`, err.Error())
+	assert.Equal(t, 0, len(p.pluckers))
+}
@@ -0,0 +1,332 @@
+package pluck
+
+import (
+	"regexp"
+	"regexp/syntax"
+	"strings"
+	"unicode"
+
+	// external
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// regexPrefix marks an activator, a deactivator or a finisher
+// as a regular expression.
+const regexPrefix = "re:"
+
+// maxRegexMatch is the maximum length of a match of a regular expression:
+// a match is looked for in the last maxRegexMatch bytes only, after each
+// byte that can end a match (see lastBytes). A regular expression ending
+// with any character, such as "a.", is thus looked for after almost every
+// byte, which makes it up to maxRegexMatch times slower than the others.
+const maxRegexMatch = 4096
+
+// pattern is a compiled activator, deactivator or finisher,
+// which can be shared by concurrent runs.
+type pattern interface {
+	// newMatcher returns a matcher looking for the pattern.
+	newMatcher() matcher
+}
+
+// matcher finds a pattern in bytes that are fed one at a time.
+type matcher interface {
+	// feed reads the next byte and returns the length of the
+	// match ending at this byte, or 0 if there is none.
+	feed(b byte) int
+	// reset forgets the bytes read so far.
+	reset()
+}
+
+// compilePattern compiles a pattern, which is a regular expression
+// if isRegex is set or if it starts with "re:".
+func compilePattern(s string, isRegex bool) (pattern, error) {
+	if strings.HasPrefix(s, regexPrefix) {
+		s = strings.TrimPrefix(s, regexPrefix)
+		isRegex = true
+	}
+	if !isRegex {
+		return newLiteralPattern([]byte(s)), nil
+	}
+	return newRegexPattern(s)
+}
+
+// literalPattern is a plain sequence of bytes, matched with the
+// Knuth-Morris-Pratt algorithm so that no occurrence is missed
+// when a partial match overlaps the beginning of another one.
+type literalPattern struct {
+	pattern []byte
+	// fail[j] is the length of the longest proper prefix
+	// of pattern[:j+1] which is also a suffix of it
+	fail []int
+}
+
+func newLiteralPattern(pattern []byte) *literalPattern {
+	lp := &literalPattern{pattern: pattern, fail: make([]int, len(pattern))}
+	for j, k := 1, 0; j < len(pattern); j++ {
+		for k > 0 && pattern[j] != pattern[k] {
+			k = lp.fail[k-1]
+		}
+		if pattern[j] == pattern[k] {
+			k++
+		}
+		lp.fail[j] = k
+	}
+	return lp
+}
+
+func (lp *literalPattern) newMatcher() matcher {
+	return &literalMatcher{literalPattern: lp}
+}
+
+type literalMatcher struct {
+	*literalPattern
+	i int
+}
+
+func (m *literalMatcher) feed(b byte) int {
+	if len(m.pattern) == 0 {
+		return 0
+	}
+	for m.i > 0 && b != m.pattern[m.i] {
+		m.i = m.fail[m.i-1]
+	}
+	if b == m.pattern[m.i] {
+		m.i++
+		if m.i == len(m.pattern) {
+			// the matched bytes are consumed
+			m.i = 0
+			return len(m.pattern)
+		}
+	}
+	return 0
+}
+
+func (m *literalMatcher) reset() {
+	m.i = 0
+}
+
+// regexPattern is a regular expression, matched by looking for the
+// longest match ending at each byte that can end a match. The first
+// byte where a match ends wins, so a trailing repetition such as
+// "a+" stops at its first repeat.
+type regexPattern struct {
+	re   *regexp.Regexp
+	last *[256]bool
+}
+
+func newRegexPattern(s string) (*regexPattern, error) {
+	re, err := regexp.Compile("(?:" + s + ")$")
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid regular expression")
+	}
+	if re.MatchString("") {
+		return nil, errors.Errorf("regular expression '%s' matches an empty string", s)
+	}
+	rp := &regexPattern{re: re}
+	if parsed, err := syntax.Parse(s, syntax.Perl); err == nil {
+		parsed = parsed.Simplify()
+		if hasBeginAnchor(parsed) {
+			// the window of the matcher does not start where the input or a line does
+			return nil, errors.Errorf("regular expression '%s' anchors at the beginning of the text or a line, which is not supported", s)
+		}
+		if endsWithRepeat(parsed) {
+			log.Warnf("regular expression '%s' ends with an unbounded repetition, which stops at its first repeat", s)
+		}
+		rp.last = lastBytes(parsed)
+	}
+	return rp, nil
+}
+
+func (rp *regexPattern) newMatcher() matcher {
+	return &regexMatcher{regexPattern: rp}
+}
+
+type regexMatcher struct {
+	*regexPattern
+	window []byte
+}
+
+func (m *regexMatcher) feed(b byte) int {
+	if len(m.window) == 2*maxRegexMatch {
+		// drop the bytes that are too far away to be part of a match
+		m.window = m.window[:copy(m.window, m.window[maxRegexMatch:])]
+	}
+	m.window = append(m.window, b)
+	if m.last != nil && !m.last[b] {
+		return 0
+	}
+	window := m.window
+	if len(window) > maxRegexMatch {
+		window = window[len(window)-maxRegexMatch:]
+	}
+	loc := m.re.FindIndex(window)
+	if loc == nil {
+		return 0
+	}
+	m.window = m.window[:0]
+	return loc[1] - loc[0]
+}
+
+func (m *regexMatcher) reset() {
+	m.window = m.window[:0]
+}
+
+// lastBytes returns the set of bytes that can end a match of
+// the regular expression, or nil if it cannot be determined.
+func lastBytes(re *syntax.Regexp) *[256]bool {
+	var set [256]bool
+	if !addLastBytes(&set, re) {
+		return nil
+	}
+	return &set
+}
+
+// addLastBytes adds the bytes that can end a non empty match of re to the
+// set, and returns false if they cannot be determined.
+func addLastBytes(set *[256]bool, re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpEmptyMatch, syntax.OpBeginLine, syntax.OpEndLine,
+		syntax.OpBeginText, syntax.OpEndText,
+		syntax.OpWordBoundary, syntax.OpNoWordBoundary:
+		return true
+	case syntax.OpLiteral:
+		if len(re.Rune) == 0 {
+			return true
+		}
+		r := re.Rune[len(re.Rune)-1]
+		addRune(set, r)
+		if re.Flags&syntax.FoldCase != 0 {
+			for f := unicode.SimpleFold(r); f != r; f = unicode.SimpleFold(f) {
+				addRune(set, f)
+			}
+		}
+		return true
+	case syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		for b := range set {
+			if b != '\n' || re.Op == syntax.OpAnyChar {
+				set[b] = true
+			}
+		}
+		return true
+	case syntax.OpCharClass:
+		for i := 0; i+1 < len(re.Rune); i += 2 {
+			for r := re.Rune[i]; r <= re.Rune[i+1] && r < 0x80; r++ {
+				set[r] = true
+			}
+			if re.Rune[i+1] >= 0x80 {
+				addRune(set, 0x80)
+			}
+		}
+		return true
+	case syntax.OpCapture, syntax.OpStar, syntax.OpPlus, syntax.OpQuest, syntax.OpRepeat:
+		return addLastBytes(set, re.Sub[0])
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if !addLastBytes(set, sub) {
+				return false
+			}
+		}
+		return true
+	case syntax.OpConcat:
+		// the match ends with the last sub-expression that cannot be empty,
+		// or any of the (possibly empty) sub-expressions following it
+		for i := len(re.Sub) - 1; i >= 0; i-- {
+			if !addLastBytes(set, re.Sub[i]) {
+				return false
+			}
+			if !canBeEmpty(re.Sub[i]) {
+				return true
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// addRune adds the last byte of the UTF-8 encoding of r to the set,
+// all the continuation bytes being added for non ASCII runes.
+func addRune(set *[256]bool, r rune) {
+	if r < 0x80 {
+		set[r] = true
+		return
+	}
+	for b := 0x80; b < 0xc0; b++ {
+		set[b] = true
+	}
+}
+
+// hasBeginAnchor reports whether re uses ^ or \A.
+func hasBeginAnchor(re *syntax.Regexp) bool {
+	if re.Op == syntax.OpBeginLine || re.Op == syntax.OpBeginText {
+		return true
+	}
+	for _, sub := range re.Sub {
+		if hasBeginAnchor(sub) {
+			return true
+		}
+	}
+	return false
+}
+
+// endsWithRepeat reports whether a match of re can end with a
+// repetition without upper bound, such as "a+" or "\s*".
+func endsWithRepeat(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpStar, syntax.OpPlus:
+		return true
+	case syntax.OpRepeat:
+		return re.Max == -1 || endsWithRepeat(re.Sub[0])
+	case syntax.OpCapture, syntax.OpQuest:
+		return endsWithRepeat(re.Sub[0])
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if endsWithRepeat(sub) {
+				return true
+			}
+		}
+	case syntax.OpConcat:
+		// as in addLastBytes, the sub-expressions after the last one
+		// which cannot be empty can end the match too
+		for i := len(re.Sub) - 1; i >= 0; i-- {
+			if endsWithRepeat(re.Sub[i]) {
+				return true
+			}
+			if !canBeEmpty(re.Sub[i]) {
+				return false
+			}
+		}
+	}
+	return false
+}
+
+// canBeEmpty reports whether re can match an empty string.
+func canBeEmpty(re *syntax.Regexp) bool {
+	switch re.Op {
+	case syntax.OpLiteral:
+		return len(re.Rune) == 0
+	case syntax.OpCharClass, syntax.OpAnyChar, syntax.OpAnyCharNotNL:
+		return false
+	case syntax.OpStar, syntax.OpQuest:
+		return true
+	case syntax.OpRepeat:
+		return re.Min == 0 || canBeEmpty(re.Sub[0])
+	case syntax.OpCapture, syntax.OpPlus:
+		return canBeEmpty(re.Sub[0])
+	case syntax.OpAlternate:
+		for _, sub := range re.Sub {
+			if canBeEmpty(sub) {
+				return true
+			}
+		}
+		return false
+	case syntax.OpConcat:
+		for _, sub := range re.Sub {
+			if !canBeEmpty(sub) {
+				return false
+			}
+		}
+		return true
+	}
+	return true
+}
@@ -0,0 +1,233 @@
+package pluck
+
+import (
+	"math/rand"
+	"regexp"
+	"regexp/syntax"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+func TestRegexActivators(t *testing.T) {
+	html := `<a  href="link1">1</a>
+<A
+HREF='link2'>2</A>
+<a class="x" href="link3">3</a>`
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		err := p.Add(config.Config{
+			Activators:  []string{`(?i)<a\s`, `(?i)href\s*=\s*["']`},
+			Deactivator: `["']`,
+			Regex:       true,
+		})
+		assert.Nil(t, err)
+		p.PluckString(html, stream)
+		assert.Equal(t, `{"0":["link1","link2","link3"]}`, p.ResultJSON())
+	}
+}
+
+func TestRegexPrefix(t *testing.T) {
+	p, _ := New()
+	err := p.Add(config.Config{
+		Activators:  []string{"price:", `re:\s*\$`},
+		Deactivator: `re:[^0-9.]`,
+		Finisher:    "total",
+	})
+	assert.Nil(t, err)
+	p.PluckString("price: $12.50; price:$3 each, total price: $15.50;")
+	assert.Equal(t, `{"0":["12.50","3"]}`, p.ResultJSON())
+
+	// the deactivator match is removed from the capture
+	p, _ = New()
+	p.Add(config.Config{
+		Activators:  []string{"<p>"},
+		Deactivator: `re:</p\s*>`,
+	})
+	p.PluckString("<p>one</p ><p>two</p>")
+	assert.Equal(t, `{"0":["one","two"]}`, p.ResultJSON())
+}
+
+func TestRegexErrors(t *testing.T) {
+	p, _ := New()
+	err := p.Add(config.Config{
+		Name:        "links",
+		Activators:  []string{"<a", `re:href=(`},
+		Deactivator: `"`,
+	})
+	assert.Contains(t, err.Error(), "problem adding plucker links: activator 1: invalid regular expression")
+
+	err = p.Add(config.Config{
+		Activators:  []string{"<a"},
+		Deactivator: `\s*`,
+		Regex:       true,
+	})
+	assert.Contains(t, err.Error(), `deactivator: regular expression '\s*' matches an empty string`)
+
+	err = p.Add(config.Config{
+		Activators:  []string{`re:(?m)^x`},
+		Deactivator: "y",
+	})
+	assert.Contains(t, err.Error(), `activator 0: regular expression '(?m)^x' anchors at the beginning of the text or a line, which is not supported`)
+	assert.Equal(t, 0, len(p.pluckers))
+}
+
+func TestRegexEarliestEnd(t *testing.T) {
+	// a match ends at the first byte where the regular expression
+	// matches, so a trailing repetition stops at its first repeat
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		assert.Nil(t, p.Add(config.Config{Activators: []string{`a+`}, Deactivator: `b+`, Regex: true}))
+		p.PluckString("aaaXbbbYaZb", stream)
+		assert.Equal(t, `{"0":["aaX","Z"]}`, p.ResultJSON())
+
+		// a bounded ending does not
+		p, _ = New()
+		assert.Nil(t, p.Add(config.Config{Activators: []string{`a+X`}, Deactivator: `Y`, Regex: true}))
+		p.PluckString("aaaXbbbYaZb", stream)
+		assert.Equal(t, `{"0":"bbb"}`, p.ResultJSON())
+	}
+}
+
+func TestEndsWithRepeat(t *testing.T) {
+	for re, expected := range map[string]bool{
+		`a+`:        true,
+		`\s*`:       true,
+		`a{2,}`:     true,
+		`(x|y+)`:    true,
+		`a+b?`:      true,
+		`a+b`:       false,
+		`a{2,3}`:    false,
+		`[^0-9.]`:   false,
+		`\d+\.\d\d`: false,
+	} {
+		parsed, err := syntax.Parse(re, syntax.Perl)
+		assert.Nil(t, err)
+		assert.Equal(t, expected, endsWithRepeat(parsed.Simplify()), re)
+	}
+}
+
+func TestLastBytes(t *testing.T) {
+	for _, tc := range []struct {
+		re       string
+		expected string
+	}{
+		{`href`, "f"},
+		{`(?i)href`, "Ff"},
+		{`a[bc]?`, "abc"},
+		{`(x|yz)\s*`, "\t\n\f\r xz"},
+		{`[0-9]+\b`, "0123456789"},
+	} {
+		parsed, err := syntax.Parse(tc.re, syntax.Perl)
+		assert.Nil(t, err)
+		set := lastBytes(parsed.Simplify())
+		if assert.NotNil(t, set, tc.re) {
+			s := ""
+			for b := 0; b < 256; b++ {
+				if set[b] {
+					s += string(rune(b))
+				}
+			}
+			assert.Equal(t, tc.expected, s, tc.re)
+		}
+	}
+	// any character but a newline, or any byte at all
+	parsed, _ := syntax.Parse(`a.`, syntax.Perl)
+	if set := lastBytes(parsed.Simplify()); assert.NotNil(t, set) {
+		assert.False(t, set['\n'])
+		assert.True(t, set['a'] && set[0x80] && set[0xff])
+	}
+	parsed, _ = syntax.Parse(`(?s)a.`, syntax.Perl)
+	if set := lastBytes(parsed.Simplify()); assert.NotNil(t, set) {
+		assert.True(t, set['\n'])
+	}
+}
+
+func TestLiteralMatcherOverlap(t *testing.T) {
+	for _, tc := range []struct {
+		pattern, input string
+		ends           []int
+	}{
+		{"aab", "aaab", []int{3}},
+		{"abab", "abababab", []int{3, 7}},
+		{"abac", "ababac", []int{5}},
+		{"aa", "aaaaa", []int{1, 3}},
+	} {
+		m := newLiteralPattern([]byte(tc.pattern)).newMatcher()
+		var ends []int
+		for i := 0; i < len(tc.input); i++ {
+			if n := m.feed(tc.input[i]); n > 0 {
+				assert.Equal(t, len(tc.pattern), n)
+				ends = append(ends, i)
+			}
+		}
+		assert.Equal(t, tc.ends, ends, "%s in %s", tc.pattern, tc.input)
+	}
+
+	p, _ := New()
+	p.Add(config.Config{
+		Activators:  []string{"aab"},
+		Deactivator: "aab",
+	})
+	p.PluckString("aaabxyzaaab")
+	assert.Equal(t, `{"0":"xyza"}`, p.ResultJSON())
+}
+
+// referencePluck extracts the captures of a plucker without finisher,
+// using a regular expression of the activators and the deactivator.
+func referencePluck(input string, activators []string, deactivator string, permanent int) []string {
+	var captured []string
+	for pos, k := 0, 0; ; k = permanent {
+		expr := `(?s)^`
+		for _, a := range activators[k:] {
+			expr += `.*?` + regexp.QuoteMeta(a)
+		}
+		expr += `(.*?)` + regexp.QuoteMeta(deactivator)
+		loc := regexp.MustCompile(expr).FindStringSubmatchIndex(input[pos:])
+		if loc == nil {
+			return captured
+		}
+		captured = append(captured, input[pos+loc[2]:pos+loc[3]])
+		pos += loc[1]
+	}
+}
+
+func TestMatcherAgainstRegex(t *testing.T) {
+	r := rand.New(rand.NewSource(1))
+	randomString := func(alphabet string, min, max int) string {
+		b := make([]byte, min+r.Intn(max-min+1))
+		for i := range b {
+			b[i] = alphabet[r.Intn(len(alphabet))]
+		}
+		return string(b)
+	}
+	for n := 0; n < 2000; n++ {
+		activators := make([]string, r.Intn(4))
+		for i := range activators {
+			activators[i] = randomString("ab", 1, 3)
+		}
+		deactivator := randomString("abc", 1, 3)
+		permanent := r.Intn(len(activators) + 1)
+		input := randomString("abc", 0, 60)
+
+		var expected interface{} = ""
+		if captured := referencePluck(input, activators, deactivator, permanent); len(captured) == 1 {
+			expected = captured[0]
+		} else if len(captured) > 1 {
+			expected = captured
+		}
+		for _, stream := range []bool{false, true} {
+			p, _ := New()
+			p.Add(config.Config{
+				Activators:  activators,
+				Deactivator: deactivator,
+				Permanent:   permanent,
+			})
+			p.PluckString(input, stream)
+			assert.Equal(t, expected, p.Result()["0"], "activators %q, deactivator %q, permanent %d, input %q, stream %v",
+				activators, deactivator, permanent, input, stream)
+		}
+	}
+}
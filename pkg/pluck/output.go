@@ -9,23 +9,54 @@ import (
 	log "github.com/sirupsen/logrus"
 )
 
-// ResultJSON returns the result, formatted as JSON.
+// Result returns the raw result
+func (p *Plucker) Result() map[string]interface{} {
+	return p.TypedResult().Map()
+}
+
+// TypedResult returns the captures of the last plucking,
+// or nil if nothing was plucked since the last reset.
+func (p *Plucker) TypedResult() *Result {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	return p.result
+}
+
+// ResultJSON returns the result, formatted as JSON, with the
+// positions of the captures if they are enabled.
 // If their are no results, it returns an empty string.
 func (p *Plucker) ResultJSON(indent ...bool) string {
+	p.mu.RLock()
+	positions := p.positions
+	p.mu.RUnlock()
+	if positions {
+		return p.TypedResult().PositionsJSON(indent...)
+	}
+	return resultJSON(p.Result(), len(indent) > 0 && indent[0])
+}
+
+// Positions toggles the positions of the captures in ResultJSON.
+func (p *Plucker) Positions(withPositions bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.positions = withPositions
+}
+
+func resultJSON(result map[string]interface{}, indent bool) string {
 	totalResults := 0
-	for key := range p.result {
-		b, _ := json.Marshal(p.result[key])
+	for key := range result {
+		b, _ := json.Marshal(result[key])
 		totalResults += len(b)
 	}
-	if totalResults == len(p.result)*2 { // results == 2 because its just []
+	if totalResults == len(result)*2 { // results == 2 because its just []
 		return ""
 	}
 	var err error
 	var resultJSON []byte
-	if len(indent) > 0 && indent[0] {
-		resultJSON, err = json.MarshalIndent(p.result, "", "    ")
+	if indent {
+		resultJSON, err = json.MarshalIndent(result, "", "    ")
 	} else {
-		resultJSON, err = json.Marshal(p.result)
+		resultJSON, err = json.Marshal(result)
 	}
 	if err != nil {
 		log.Error(errors.Wrap(err, "result marshalling failed"))
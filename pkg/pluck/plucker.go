@@ -3,36 +3,48 @@ package pluck
 import (
 	"bufio"
 	"bytes"
+	"context"
 
 	"html"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"sort"
 	"strconv"
 	"strings"
 	"sync"
 
 	// external
-	"github.com/BurntSushi/toml"
 	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 	// pp "github.com/sniperkit/colly/plugins/app/debug/pp"
 
 	// internal
 	config "github.com/sniperkit/pluck/pkg/config"
+	query "github.com/sniperkit/pluck/pkg/query"
 	striphtml "github.com/sniperkit/pluck/pkg/striphtml"
+	transform "github.com/sniperkit/pluck/pkg/transform"
 )
 
-// Plucker stores the result and the types of things to pluck
+// Plucker stores the result and the types of things to pluck.
+// The Extract functions can be called concurrently, each of them
+// returning its own result, while the Pluck functions store the
+// result in the Plucker.
 type Plucker struct {
-	pluckers []pluckUnit
-	result   map[string]interface{}
+	mu        sync.RWMutex
+	pluckers  []pluckUnit
+	result    *Result
+	positions bool
+	http      httpOptions  // set by New
+	client    *http.Client // set by New
 }
 
+// pluckUnit is the compiled configuration of a plucker,
+// which is never modified while plucking.
 type pluckUnit struct {
 	config       config.Config
-	activators   [][]byte
+	activators   []pattern
 	patterns     [][]byte
 	whitelist    [][]byte
 	blacklist    [][]byte
@@ -40,28 +52,64 @@ type pluckUnit struct {
 	maximum      int
 	autoSplit    bool
 	separator    []byte
-	matchMode    []byte
+	matchMode    config.MatchMode
 	matchPhrase  []byte
-	deactivator  []byte
-	finisher     []byte
-	captured     [][]byte
+	query        query.Expr
+	deactivator  pattern
+	finisher     pattern
+	captureLimit int
+	overflow     config.OverflowMode
+	children     *Plucker
+	record       bool
+	transforms   []namedTransform
+	valueType    config.ValueType
+	decimal      rune   // decimal separator of numbers
+	layout       string // layout of dates
+}
+
+// unitState is the state of a plucker while plucking an input.
+type unitState struct {
+	unit         *pluckUnit
+	activators   []matcher
+	deactivator  matcher
+	finisher     matcher
 	numActivated int
 	captureByte  []byte
-	captureI     int
-	activeI      int
-	deactiveI    int
-	finisherI    int
+	captureN     int
+	captureStart position
+	captured     []Capture
+	numCaptured  int
+	numSkipped   int      // captures skipped for exceeding the capture limit
+	chunk        []byte   // chunk of the input being fed
+	chunkStart   position // position of the chunk in the input
+	k            int      // index in the chunk of the byte being fed
+	cursor       position // position of chunk[cursorK]
+	cursorK      int
 	isFinished   bool
+	err          error
+	env          *transform.Env      // input the captures come from
+	seen         map[string]struct{} // texts kept by a unique plucker
 }
 
 // New returns a new plucker
 // which can later have items added to it
 // or can load a config file
 // and then can be used to parse.
-func New() (*Plucker, error) {
+// The options set how pages are downloaded.
+func New(options ...Option) (*Plucker, error) {
 	log.SetLevel(log.WarnLevel)
 	p := new(Plucker)
 	p.pluckers = []pluckUnit{}
+	p.http.header = make(http.Header)
+	for _, option := range options {
+		if err := option(&p.http); err != nil {
+			return nil, errors.Wrap(err, "problem creating plucker")
+		}
+	}
+	var err error
+	if p.client, err = p.http.newClient(); err != nil {
+		return nil, errors.Wrap(err, "problem creating plucker")
+	}
 	return p, nil
 }
 
@@ -77,6 +125,8 @@ func (p *Plucker) Verbose(makeVerbose bool) {
 // Configuration returns an array of the current
 // Config for each plucker.
 func (p *Plucker) Configuration() (c []config.Config) {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
 	c = make([]config.Config, len(p.pluckers))
 	for i, unit := range p.pluckers {
 		c[i] = unit.config
@@ -86,7 +136,9 @@ func (p *Plucker) Configuration() (c []config.Config) {
 
 // Add adds a unit
 // to pluck with specified parameters
-func (p *Plucker) Add(c config.Config) {
+func (p *Plucker) Add(c config.Config) (err error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
 	var u pluckUnit
 	u.config = c
 	if u.config.Limit == 0 {
@@ -95,15 +147,32 @@ func (p *Plucker) Add(c config.Config) {
 	if u.config.Name == "" {
 		u.config.Name = strconv.Itoa(len(p.pluckers))
 	}
-	u.activators = make([][]byte, len(c.Activators))
+
+	// `record` is both the activator and the deactivator
+	if c.Record != "" {
+		if len(c.Activators) > 0 || c.Deactivator != "" {
+			return errors.Errorf("problem adding plucker %s: a record cannot have activators or a deactivator", u.config.Name)
+		}
+		c.Activators = []string{c.Record}
+		c.Deactivator = c.Record
+		c.Permanent = 1
+		u.record = true
+	}
+	u.activators = make([]pattern, len(c.Activators))
 	for i := range c.Activators {
-		u.activators[i] = []byte(c.Activators[i])
+		if u.activators[i], err = compilePattern(c.Activators[i], c.Regex); err != nil {
+			return errors.Wrapf(err, "problem adding plucker %s: activator %d", u.config.Name, i)
+		}
 	}
 
 	u.permanent = c.Permanent
-	u.deactivator = []byte(c.Deactivator)
+	if u.deactivator, err = compilePattern(c.Deactivator, c.Regex); err != nil {
+		return errors.Wrapf(err, "problem adding plucker %s: deactivator", u.config.Name)
+	}
 	if len(c.Finisher) > 0 {
-		u.finisher = []byte(c.Finisher)
+		if u.finisher, err = compilePattern(c.Finisher, c.Regex); err != nil {
+			return errors.Wrapf(err, "problem adding plucker %s: finisher", u.config.Name)
+		}
 	} else {
 		u.finisher = nil
 	}
@@ -112,19 +181,17 @@ func (p *Plucker) Add(c config.Config) {
 		u.maximum = c.Maximum
 	}
 
-	// matchMode
-	u.matchMode = []byte(c.Match.Mode)
-
-	// matchPhrase
-	u.matchPhrase = []byte(c.Match.Phrase)
-
-	// separator
-	u.separator = []byte(c.Match.Separator)
+	// captureLimit and overflow
+	if err = u.compileCapture(c); err != nil {
+		return errors.Wrap(err, "problem adding plucker "+u.config.Name)
+	}
 
-	// autoSplit
-	u.autoSplit = c.Match.Split
+	// matchMode, matchPhrase, separator and autoSplit
+	if err = u.compileMatch(c); err != nil {
+		return errors.Wrap(err, "problem adding plucker "+u.config.Name)
+	}
 
-	// `patterns` is a list of words to extract as a sub-result tree
+	// `patterns` specifies the word list checked with the matching mode
 	u.patterns = make([][]byte, len(c.Patterns))
 	for i := range c.Patterns {
 		u.patterns[i] = []byte(c.Patterns[i])
@@ -142,46 +209,50 @@ func (p *Plucker) Add(c config.Config) {
 		u.blacklist[i] = []byte(c.Blacklist[i])
 	}
 
-	u.captureByte = make([]byte, 100000)
-	u.captured = [][]byte{}
+	// `transforms` are applied in order to each capture
+	if err = u.compileTransforms(c); err != nil {
+		return errors.Wrap(err, "problem adding plucker "+u.config.Name)
+	}
+
+	// `type` and `format` convert each capture
+	if err = u.compileType(c); err != nil {
+		return errors.Wrap(err, "problem adding plucker "+u.config.Name)
+	}
+
+	// `children` are applied to each capture
+	if len(c.Children) > 0 {
+		// a bare plucker: New would reset the log level and build an HTTP client
+		u.children = &Plucker{}
+		for i := range c.Children {
+			if err = u.children.Add(c.Children[i]); err != nil {
+				return errors.Wrap(err, "problem adding plucker "+u.config.Name)
+			}
+		}
+	}
+
 	p.pluckers = append(p.pluckers, u)
 	log.Infof("Added plucker %+v", c)
+	return
 }
 
-// Load will load a TOML configuration file of untis
-// to pluck with specified parameters
+// Load will load a configuration file of untis
+// to pluck with specified parameters, its format
+// (toml, yaml, json, xml or ini) being detected
+// from its extension or else its content
 func (p *Plucker) Load(f string) (err error) {
 	log.Debugf("load config file at: %s", f)
 
-	var conf *config.Configs
-	conf, err = config.NewFromFile(false, false, false, f)
+	conf, err := config.ParseFile(f, "")
 	if err != nil {
-		return errors.Wrap(err, "problem opening config file "+f)
+		return err
 	}
-
-	for i := range conf.Pluck {
-		var c config.Config
-		c.Activators = conf.Pluck[i].Activators
-		c.Deactivator = conf.Pluck[i].Deactivator
-		c.Finisher = conf.Pluck[i].Finisher
-		c.Limit = conf.Pluck[i].Limit
-		c.Name = conf.Pluck[i].Name
-		c.Permanent = conf.Pluck[i].Permanent
-		c.Sanitize = conf.Pluck[i].Sanitize
-		c.Maximum = conf.Pluck[i].Maximum
-		p.Add(c)
+	if err = p.LoadConfigs(conf); err != nil {
+		return errors.Wrap(err, "problem loading config file "+f)
 	}
-
-	// Dump config file for dev purpise
-	dumpFormats := []string{"yaml", "json", "toml", "xml"}
-	dumpNodes := []string{}
-	config.Dump(conf, dumpFormats, dumpNodes, "./conf/schema/plucker") // use string slices
-	// pp.Println(appConfig)
-
 	return
 }
 
-// Load will load a TOML configuration file of untis
+// LoadTOML will load a TOML configuration file of untis
 // to pluck with specified parameters
 func (p *Plucker) LoadTOML(f string) (err error) {
 	tomlData, err := ioutil.ReadFile(f)
@@ -189,272 +260,471 @@ func (p *Plucker) LoadTOML(f string) (err error) {
 		return errors.Wrap(err, "problem opening "+f)
 	}
 	log.Debugf("toml string: %s", string(tomlData))
-	p.LoadFromString(string(tomlData))
-	return
+	return p.LoadFromString(string(tomlData))
 }
 
-// LoadFromString will load a YAML configuration file of untis
+// LoadFromString will load a TOML configuration of untis
 // to pluck with specified parameters
 func (p *Plucker) LoadFromString(tomlString string) (err error) {
-	var conf config.Configs
-	_, err = toml.Decode(tomlString, &conf)
-	log.Debugf("Loaded toml: %+v", conf)
-	for i := range conf.Pluck {
-		var c config.Config
-		c.Activators = conf.Pluck[i].Activators
-		c.Deactivator = conf.Pluck[i].Deactivator
-		c.Finisher = conf.Pluck[i].Finisher
-		c.Limit = conf.Pluck[i].Limit
-		c.Name = conf.Pluck[i].Name
-		c.Permanent = conf.Pluck[i].Permanent
-		c.Sanitize = conf.Pluck[i].Sanitize
-		c.Maximum = conf.Pluck[i].Maximum
-		p.Add(c)
+	return p.LoadFromReader(strings.NewReader(tomlString), "toml")
+}
+
+// LoadFromReader will load a configuration of untis to pluck
+// in the given format (toml, yaml, json, xml or ini), or
+// in the format detected from its content if empty
+func (p *Plucker) LoadFromReader(r io.Reader, format string) (err error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return errors.Wrap(err, "problem reading config")
 	}
-	return
+	if format == "" {
+		format = config.DetectFormat("", data)
+	}
+	conf, err := config.Parse(data, format)
+	if err != nil {
+		return err
+	}
+	log.Debugf("Loaded %s: %+v", format, conf)
+	return p.LoadConfigs(conf)
+}
+
+// LoadConfigs adds the enabled pluckers of a configuration, such as
+// one found by config.Discover: all of them, or none if any cannot
+// be added, the errors being gathered.
+func (p *Plucker) LoadConfigs(conf *config.Configs) error {
+	p.mu.RLock()
+	n := len(p.pluckers)
+	p.mu.RUnlock()
+	added := &Plucker{} // staging, as for children
+	var errs config.Errors
+	for _, c := range conf.Pluck {
+		if !c.IsEnabled() {
+			continue
+		}
+		c = enabledChildren(c)
+		if c.Name == "" {
+			c.Name = strconv.Itoa(n + len(added.pluckers))
+		}
+		if err := added.Add(c); err != nil {
+			errs = append(errs, &config.Error{Line: c.Line(), Err: err})
+		}
+	}
+	if len(errs) > 0 {
+		return errs
+	}
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pluckers = append(p.pluckers, added.pluckers...)
+	return nil
+}
+
+// enabledChildren returns c without its disabled children.
+func enabledChildren(c config.Config) config.Config {
+	children := c.Children
+	c.Children = nil
+	for _, child := range children {
+		if child.IsEnabled() {
+			c.Children = append(c.Children, enabledChildren(child))
+		}
+	}
+	return c
 }
 
 // PluckString takes a string as input
 // and uses the specified parameters and generates
-// a map (p.result) with the finished results.
+// a result (p.result) with the finished results.
 // The streaming can be enabled by setting it to true.
 func (p *Plucker) PluckString(s string, stream ...bool) (err error) {
-	r := bufio.NewReader(strings.NewReader(s))
-	if len(stream) > 0 && stream[0] {
-		return p.PluckStream(r)
-	}
-	return p.Pluck(r)
+	result, err := p.ExtractString(s, stream...)
+	p.setResult(result)
+	return
 }
 
 // PluckFile takes a file as input
 // and uses the specified parameters and generates
-// a map (p.result) with the finished results. The streaming
+// a result (p.result) with the finished results. The streaming
 // can be enabled by setting it to true.
 func (p *Plucker) PluckFile(f string, stream ...bool) (err error) {
-	r1, err := os.Open(f)
-	defer r1.Close()
-	if err != nil {
-		return
-	}
-	r := bufio.NewReader(r1)
-	if len(stream) > 0 && stream[0] {
-		return p.PluckStream(r)
-	}
-	return p.Pluck(r)
+	result, err := p.ExtractFile(f, stream...)
+	p.setResult(result)
+	return
 }
 
 // PluckURL takes a URL as input
 // and uses the specified parameters and generates
-// a map (p.result) with the finished results
+// a result (p.result) with the finished results
 func (p *Plucker) PluckURL(url string, stream ...bool) (err error) {
-	client := &http.Client{}
-	request, err := http.NewRequest("GET", url, nil)
+	result, err := p.ExtractURL(url, stream...)
+	p.setResult(result)
+	return
+}
+
+// Pluck takes a buffered reader stream and
+// extracts the text from it. This spawns a thread for
+// each plucker and copies the entire buffer to memory,
+// so that each plucker works in parallel.
+func (p *Plucker) Pluck(r *bufio.Reader) (err error) {
+	return p.PluckContext(context.Background(), r)
+}
+
+// PluckStream takes a buffered reader stream and reads it in
+// chunks of 64KiB, which each plucker goes through in turn,
+// skipping ahead to the next possible match (see feedChunk and
+// fastForward), so that only the chunk and the open captures
+// are kept in memory.
+func (p *Plucker) PluckStream(r *bufio.Reader) (err error) {
+	return p.PluckContext(context.Background(), r, true)
+}
+
+// PluckContext is like Pluck, or PluckStream if stream is set to true,
+// but stops as soon as the context is done. The result then holds the
+// captures found so far and the context error is returned.
+func (p *Plucker) PluckContext(ctx context.Context, r *bufio.Reader, stream ...bool) (err error) {
+	result, err := p.ExtractContext(ctx, r, stream...)
+	p.setResult(result)
+	return
+}
+
+// PluckURLContext is like PluckURL but stops downloading and
+// plucking as soon as the context is done.
+func (p *Plucker) PluckURLContext(ctx context.Context, url string, stream ...bool) (err error) {
+	result, err := p.ExtractURLContext(ctx, url, stream...)
+	p.setResult(result)
+	return
+}
+
+// Extract takes a reader as input and returns the result of plucking
+// it, without modifying the Plucker, so that it can be called from
+// several goroutines at once. The streaming can be enabled by setting
+// it to true.
+func (p *Plucker) Extract(r io.Reader, stream ...bool) (*Result, error) {
+	return p.ExtractContext(context.Background(), r, stream...)
+}
+
+// ExtractContext is like Extract but stops as soon as the context is
+// done, returning the captures found so far and the context error.
+func (p *Plucker) ExtractContext(ctx context.Context, r io.Reader, stream ...bool) (*Result, error) {
+	return p.extractContext(ctx, r, nil, stream...)
+}
+
+// extractContext is like ExtractContext, the captures
+// coming from the input described by env.
+func (p *Plucker) extractContext(ctx context.Context, r io.Reader, env *transform.Env, stream ...bool) (*Result, error) {
+	cr := &contextReader{ctx: ctx, r: r}
+	if len(stream) > 0 && stream[0] {
+		return p.extractStream(ctx, cr, env, nil)
+	}
+	return p.extract(ctx, cr, env)
+}
+
+// ExtractString is like Extract but takes a string as input.
+func (p *Plucker) ExtractString(s string, stream ...bool) (*Result, error) {
+	return p.Extract(strings.NewReader(s), stream...)
+}
+
+// ExtractFile is like Extract but takes a file as input.
+func (p *Plucker) ExtractFile(f string, stream ...bool) (*Result, error) {
+	r, err := os.Open(f)
 	if err != nil {
-		return
+		return nil, err
 	}
-	request.Header.Set("User-Agent", "Mozilla/5.0 (X11; Linux x86_64; rv:52.0) Gecko/20100101 Firefox/52.0")
-	resp, err := client.Do(request)
+	defer r.Close()
+	return p.Extract(r, stream...)
+}
+
+// ExtractURL is like Extract but takes a URL as input.
+func (p *Plucker) ExtractURL(url string, stream ...bool) (*Result, error) {
+	return p.ExtractURLContext(context.Background(), url, stream...)
+}
+
+// ExtractURLContext is like ExtractURL but stops downloading and
+// plucking as soon as the context is done. Relative URLs are resolved
+// against the URL of the page after redirects, or its <base href>.
+// The page is downloaded as set by the options given to New.
+func (p *Plucker) ExtractURLContext(ctx context.Context, url string, stream ...bool) (*Result, error) {
+	resp, err := p.get(ctx, url)
 	if err != nil {
-		return
+		return nil, err
 	}
 	defer resp.Body.Close()
-	r := bufio.NewReader(resp.Body)
-	if len(stream) > 0 && stream[0] {
-		return p.PluckStream(r)
+	return p.extractContext(ctx, resp.Body, &transform.Env{BaseURL: resp.Request.URL}, stream...)
+}
+
+// Reset clears the result stored by the last Pluck call.
+func (p *Plucker) Reset() {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.result = nil
+}
+
+// setResult stores the result of a Pluck call.
+func (p *Plucker) setResult(result *Result) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.result = result
+}
+
+// newStates returns a fresh state for each plucker,
+// plucking the input described by env.
+func (p *Plucker) newStates(env *transform.Env) []*unitState {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	states := make([]*unitState, len(p.pluckers))
+	for i := range p.pluckers {
+		states[i] = p.pluckers[i].newState()
+		states[i].env = env
 	}
-	return p.Pluck(r)
+	return states
 }
 
-// Pluck takes a buffered reader stream and
-// extracts the text from it. This spawns a thread for
-// each plucker and copies the entire buffer to memory,
-// so that each plucker works in parallel.
-func (p *Plucker) Pluck(r *bufio.Reader) (err error) {
-	allBytes, _ := r.ReadBytes(0)
+// newState returns the initial state of a unit.
+func (u *pluckUnit) newState() *unitState {
+	s := &unitState{
+		unit:        u,
+		activators:  make([]matcher, len(u.activators)),
+		deactivator: u.deactivator.newMatcher(),
+	}
+	for i := range u.activators {
+		s.activators[i] = u.activators[i].newMatcher()
+	}
+	if u.finisher != nil {
+		s.finisher = u.finisher.newMatcher()
+	}
+	if u.config.Unique || u.config.UniqueNormalized {
+		s.seen = make(map[string]struct{})
+	}
+	return s
+}
+
+// extract copies the entire buffer to memory and runs each
+// plucker in its own goroutine. If reading fails, for example
+// because the context is done, the bytes read so far are still
+// plucked and the result holds the captures found in them.
+func (p *Plucker) extract(ctx context.Context, r io.Reader, env *transform.Env) (*Result, error) {
+	states := p.newStates(env)
+	allBytes, err := ioutil.ReadAll(r)
+	newBaseScanner(env).scan(allBytes)
+	if err != nil {
+		// the context may be done already: pluck what was read anyway,
+		// leaving the captures open at the end of it unfinished
+		pluckParallel(context.Background(), states, allBytes, false)
+		return newResult(states), err
+	}
+	pluckParallel(ctx, states, allBytes, true)
+	if ctx.Err() != nil {
+		return newResult(states), ctx.Err()
+	}
+	return newResult(states), firstError(states)
+}
+
+// pluckParallel runs each plucker over allBytes in its own goroutine,
+// until the context is done, and finishes them if finish is set.
+func pluckParallel(ctx context.Context, states []*unitState, allBytes []byte, finish bool) {
 	var wg sync.WaitGroup
-	wg.Add(len(p.pluckers))
-	for i := 0; i < len(p.pluckers); i++ {
+	wg.Add(len(states))
+	for i := 0; i < len(states); i++ {
 		go func(i int, allBytes []byte) {
 			defer wg.Done()
-			for _, curByte := range allBytes {
-				if p.pluckers[i].numActivated < len(p.pluckers[i].activators) {
-					// look for activators
-					if curByte == p.pluckers[i].activators[p.pluckers[i].numActivated][p.pluckers[i].activeI] {
-						p.pluckers[i].activeI++
-						if p.pluckers[i].activeI == len(p.pluckers[i].activators[p.pluckers[i].numActivated]) {
-							log.Info(string(curByte), "Activated")
-							p.pluckers[i].numActivated++
-							p.pluckers[i].activeI = 0
-						}
-					} else {
-						p.pluckers[i].activeI = 0
-					}
-				} else {
-					// add to capture
-					p.pluckers[i].captureByte[p.pluckers[i].captureI] = curByte
-					p.pluckers[i].captureI++
-					// look for deactivators
-					if curByte == p.pluckers[i].deactivator[p.pluckers[i].deactiveI] {
-						p.pluckers[i].deactiveI++
-						if p.pluckers[i].deactiveI == len(p.pluckers[i].deactivator) {
-							log.Info(string(curByte), "Deactivated")
-							// add capture
-							log.Info(string(p.pluckers[i].captureByte[:p.pluckers[i].captureI-len(p.pluckers[i].deactivator)]))
-							tempByte := make([]byte, p.pluckers[i].captureI-len(p.pluckers[i].deactivator))
-							copy(tempByte, p.pluckers[i].captureByte[:p.pluckers[i].captureI-len(p.pluckers[i].deactivator)])
-							if p.pluckers[i].config.Sanitize {
-								tempByte = bytes.Replace(tempByte, []byte("\\u003c"), []byte("<"), -1)
-								tempByte = bytes.Replace(tempByte, []byte("\\u003e"), []byte(">"), -1)
-								tempByte = bytes.Replace(tempByte, []byte("\\u0026"), []byte("&"), -1)
-								tempByte = []byte(striphtml.StripTags(html.UnescapeString(string(tempByte))))
-							}
-							tempByte = bytes.TrimSpace(tempByte)
-							if p.pluckers[i].maximum < 1 || len(tempByte) < p.pluckers[i].maximum {
-								p.pluckers[i].captured = append(p.pluckers[i].captured, tempByte)
-							}
-							// reset
-							p.pluckers[i].numActivated = p.pluckers[i].permanent
-							p.pluckers[i].deactiveI = 0
-							p.pluckers[i].captureI = 0
-						}
-					} else {
-						p.pluckers[i].activeI = 0
-						p.pluckers[i].deactiveI = 0
-					}
-				}
-
-				// look for finisher
-				if p.pluckers[i].finisher != nil && len(p.pluckers[i].captured) > 0 {
-					if curByte == p.pluckers[i].finisher[p.pluckers[i].finisherI] {
-						p.pluckers[i].finisherI++
-						if p.pluckers[i].finisherI == len(p.pluckers[i].finisher) {
-							log.Info(string(curByte), "Finished")
-							p.pluckers[i].isFinished = true
-						}
-					} else {
-						p.pluckers[i].finisherI = 0
-					}
-				}
-
-				if len(p.pluckers[i].captured) == p.pluckers[i].config.Limit {
-					p.pluckers[i].isFinished = true
-				}
-				if p.pluckers[i].isFinished {
-					break
+			s := states[i]
+			pos := position{line: 1, column: 1}
+			for len(allBytes) > 0 && !s.isFinished && ctx.Err() == nil {
+				chunk := allBytes
+				if len(chunk) > chunkSize {
+					chunk = chunk[:chunkSize]
 				}
+				s.feedChunk(chunk, pos, nil)
+				pos = pos.skip(chunk)
+				allBytes = allBytes[len(chunk):]
+			}
+			if finish && ctx.Err() == nil {
+				s.finish(nil)
 			}
 			log.Infof("plucker %d finished", i)
 		}(i, allBytes)
 	}
 	wg.Wait()
-	p.generateResult()
-	return
 }
 
-// PluckStream takes a buffered reader stream and streams one
-// byte at a time and processes all pluckers serially and
-// simultaneously.
-func (p *Plucker) PluckStream(r *bufio.Reader) (err error) {
-	var finished bool
-	for {
-		curByte, errRead := r.ReadByte()
-		if errRead == io.EOF || finished {
-			break
+// extractStream reads the input in chunks and runs each plucker
+// over a chunk in turn, so that the memory used does not depend on
+// the size of the input. If emit is not nil, the captures are passed
+// to it, in the order they are found in the input, instead of being
+// stored in the result, and the first error it returns stops plucking.
+func (p *Plucker) extractStream(ctx context.Context, r io.Reader, env *transform.Env, emit func(Capture) error) (*Result, error) {
+	states := p.newStates(env)
+	chunk := make([]byte, chunkSize)
+	var found []foundCapture
+	collect := make([]func(Capture) error, len(states))
+	if emit != nil {
+		for i, s := range states {
+			s := s
+			collect[i] = func(c Capture) error {
+				found = append(found, foundCapture{at: s.offset(), capture: c})
+				return nil
+			}
 		}
-		finished = true
-		for i := range p.pluckers {
-			if p.pluckers[i].isFinished {
-				continue
+	}
+	// emitFound emits the captures of all the pluckers in input order
+	emitFound := func() error {
+		sort.SliceStable(found, func(i, j int) bool { return found[i].at < found[j].at })
+		for _, f := range found {
+			if err := emit(f.capture); err != nil {
+				return err
 			}
-			finished = false
-			if p.pluckers[i].numActivated < len(p.pluckers[i].activators) {
-				// look for activators
-				if curByte == p.pluckers[i].activators[p.pluckers[i].numActivated][p.pluckers[i].activeI] {
-					p.pluckers[i].activeI++
-					if p.pluckers[i].activeI == len(p.pluckers[i].activators[p.pluckers[i].numActivated]) {
-						log.Info(string(curByte), "Activated")
-						p.pluckers[i].numActivated++
-						p.pluckers[i].activeI = 0
-					}
-				} else {
-					p.pluckers[i].activeI = 0
-				}
-			} else {
-				// add to capture
-				p.pluckers[i].captureByte[p.pluckers[i].captureI] = curByte
-				p.pluckers[i].captureI++
-				// look for deactivators
-				if curByte == p.pluckers[i].deactivator[p.pluckers[i].deactiveI] {
-					p.pluckers[i].deactiveI++
-					if p.pluckers[i].deactiveI == len(p.pluckers[i].deactivator) {
-						log.Info(string(curByte), "Deactivated")
-						// add capture
-						log.Info(string(p.pluckers[i].captureByte[:p.pluckers[i].captureI-len(p.pluckers[i].deactivator)]))
-						tempByte := make([]byte, p.pluckers[i].captureI-len(p.pluckers[i].deactivator))
-						copy(tempByte, p.pluckers[i].captureByte[:p.pluckers[i].captureI-len(p.pluckers[i].deactivator)])
-						if p.pluckers[i].config.Sanitize {
-							tempByte = bytes.Replace(tempByte, []byte("\\u003c"), []byte("<"), -1)
-							tempByte = bytes.Replace(tempByte, []byte("\\u003e"), []byte(">"), -1)
-							tempByte = bytes.Replace(tempByte, []byte("\\u0026"), []byte("&"), -1)
-							tempByte = []byte(striphtml.StripTags(html.UnescapeString(string(tempByte))))
-						}
-						tempByte = bytes.TrimSpace(tempByte)
-						p.pluckers[i].captured = append(p.pluckers[i].captured, tempByte)
-						// reset
-						p.pluckers[i].numActivated = p.pluckers[i].permanent
-						p.pluckers[i].deactiveI = 0
-						p.pluckers[i].captureI = 0
-					}
-				} else {
-					p.pluckers[i].activeI = 0
-					p.pluckers[i].deactiveI = 0
+		}
+		found = found[:0]
+		return nil
+	}
+	bases := newBaseScanner(env)
+	pos := position{line: 1, column: 1}
+	for {
+		if err := ctx.Err(); err != nil {
+			return newResult(states), err
+		}
+		n, errRead := r.Read(chunk)
+		if n > 0 {
+			bases.scan(chunk[:n])
+			finished := true
+			for i, s := range states {
+				if !s.isFinished {
+					s.feedChunk(chunk[:n], pos, collect[i])
 				}
+				finished = finished && s.isFinished
 			}
-
-			// look for finisher
-			if p.pluckers[i].finisher != nil {
-				if curByte == p.pluckers[i].finisher[p.pluckers[i].finisherI] {
-					p.pluckers[i].finisherI++
-					if p.pluckers[i].finisherI == len(p.pluckers[i].finisher) {
-						log.Info(string(curByte), "Finished")
-						p.pluckers[i].isFinished = true
-					}
-				} else {
-					p.pluckers[i].finisherI = 0
-				}
+			pos = pos.skip(chunk[:n])
+			if err := emitFound(); err != nil {
+				return newResult(states), err
 			}
-
-			if len(p.pluckers[i].captured) == p.pluckers[i].config.Limit {
-				p.pluckers[i].isFinished = true
+			if finished {
+				break
 			}
 		}
+		if errRead == io.EOF {
+			break
+		}
+		if errRead != nil {
+			return newResult(states), errRead
+		}
 	}
-	p.generateResult()
-	return
+	for i, s := range states {
+		s.finish(collect[i])
+	}
+	if err := emitFound(); err != nil {
+		return newResult(states), err
+	}
+	return newResult(states), firstError(states)
 }
 
-func (p *Plucker) generateResult() {
-	p.result = make(map[string]interface{})
-	for i := range p.pluckers {
-		if len(p.pluckers[i].captured) == 0 {
-			p.result[p.pluckers[i].config.Name] = ""
-		} else if len(p.pluckers[i].captured) == 1 {
-			p.result[p.pluckers[i].config.Name] = string(p.pluckers[i].captured[0])
-		} else {
-			results := make([]string, len(p.pluckers[i].captured))
-			for j, r := range p.pluckers[i].captured {
-				results[j] = string(r)
-			}
-			if len(results) == 0 {
-				p.result[p.pluckers[i].config.Name] = ""
-			} else {
-				p.result[p.pluckers[i].config.Name] = results
-			}
+// extractBytes runs the pluckers one after the other over b,
+// found at the given position of the input described by env.
+func (p *Plucker) extractBytes(b []byte, start position, env *transform.Env) (*Result, error) {
+	states := p.newStates(env)
+	for _, s := range states {
+		s.feedChunk(b, start, nil)
+		s.finish(nil)
+	}
+	return newResult(states), firstError(states)
+}
+
+// foundCapture is a capture found while streaming,
+// with the offset of the byte that ended it.
+type foundCapture struct {
+	at      int
+	capture Capture
+}
+
+// contextReader stops reading once its context is done.
+type contextReader struct {
+	ctx context.Context
+	r   io.Reader
+}
+
+func (cr *contextReader) Read(b []byte) (int, error) {
+	if err := cr.ctx.Err(); err != nil {
+		return 0, err
+	}
+	return cr.r.Read(b)
+}
+
+// sanitize converts the escaped HTML characters and strips the HTML tags.
+func sanitize(b []byte) []byte {
+	b = bytes.Replace(b, []byte("\\u003c"), []byte("<"), -1)
+	b = bytes.Replace(b, []byte("\\u003e"), []byte(">"), -1)
+	b = bytes.Replace(b, []byte("\\u0026"), []byte("&"), -1)
+	return []byte(striphtml.StripTags(html.UnescapeString(string(b))))
+}
+
+// firstError returns the first error met by a plucker.
+func firstError(states []*unitState) error {
+	for _, s := range states {
+		if s.err != nil {
+			return s.err
 		}
 	}
+	return nil
 }
 
-// Result returns the raw result
-func (p *Plucker) Result() map[string]interface{} {
-	return p.result
+// addCapture filters a finished capture and stores the kept
+// occurrences once transformed, resolved and converted, or passes
+// them to emit if it is not nil, without exceeding the limit. A
+// failing transform or conversion does not stop plucking: its
+// error is kept in the capture. Duplicates of a unique plucker
+// are dropped and do not count toward the limit.
+func (s *unitState) addCapture(sp span, emit func(Capture) error) error {
+	for _, piece := range s.unit.filter(sp) {
+		if s.numCaptured == s.unit.config.Limit {
+			break
+		}
+		c := Capture{
+			Plucker:   s.unit.config.Name,
+			Text:      string(piece.text),
+			Offset:    piece.start.offset,
+			Length:    piece.length,
+			End:       piece.start.offset + piece.length,
+			Line:      piece.start.line,
+			Column:    piece.start.column,
+			Truncated: piece.truncated,
+		}
+		if len(s.unit.transforms) > 0 {
+			var err error
+			if c.Text, err = s.unit.transform(c.Text, s.env); err != nil {
+				c.Error = err.Error()
+			}
+		}
+		if s.unit.config.Resolve && c.Error == "" {
+			var err error
+			if c.Text, err = resolve(c.Text, s.env); err != nil {
+				c.Error = err.Error()
+			}
+		}
+		if s.unit.valueType != config.TYPE_STRING && c.Error == "" {
+			var err error
+			if c.Value, err = s.unit.convert(c.Text, s.env); err != nil {
+				c.Error = err.Error()
+			}
+		}
+		if s.isDuplicate(c.Text) {
+			continue
+		}
+		s.numCaptured++
+		if s.unit.children != nil {
+			// the children see the input bytes of the capture
+			text, start := piece.text, piece.start
+			if !piece.mapped {
+				text, start = piece.raw, piece.rawStart
+			}
+			var err error
+			if c.Children, err = s.unit.children.extractBytes(text, start, s.env); err != nil && s.err == nil {
+				s.err = err
+			}
+		}
+		if emit != nil {
+			if err := emit(c); err != nil {
+				return err
+			}
+			continue
+		}
+		s.captured = append(s.captured, c)
+	}
+	return nil
 }
@@ -1,17 +1,28 @@
-package pluck_test
+package pluck
 
 import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
 	"testing"
 
+	log "github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
 )
 
 func BenchmarkParseFile(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		p, _ := New()
 		p.Verbose(false)
-		p.Load("test/config.toml")
-		p.PluckFile("test/test.txt")
+		p.Load("../../tests/config.toml")
+		p.PluckFile("../../tests/test.txt")
 	}
 }
 
@@ -19,19 +30,68 @@ func BenchmarkParseFileStream(b *testing.B) {
 	for n := 0; n < b.N; n++ {
 		p, _ := New()
 		p.Verbose(false)
-		p.Load("test/config.toml")
-		p.PluckFile("test/test.txt", true)
+		p.Load("../../tests/config.toml")
+		p.PluckFile("../../tests/test.txt", true)
+	}
+}
+
+// largeInputSize is the size of the synthetic input of the large benchmarks.
+const largeInputSize = 256 << 20
+
+// repeatReader repeats a text until n bytes are read.
+type repeatReader struct {
+	text []byte
+	i, n int
+}
+
+func (r *repeatReader) Read(b []byte) (int, error) {
+	if r.n <= 0 {
+		return 0, io.EOF
+	}
+	if len(b) > r.n {
+		b = b[:r.n]
+	}
+	n := 0
+	for n < len(b) {
+		c := copy(b[n:], r.text[r.i:])
+		n += c
+		r.i = (r.i + c) % len(r.text)
+	}
+	r.n -= n
+	return n, nil
+}
+
+func benchmarkLarge(b *testing.B, stream bool) {
+	text, err := ioutil.ReadFile("../../tests/test.txt")
+	if err != nil {
+		b.Fatal(err)
+	}
+	p, _ := New()
+	p.Verbose(false)
+	p.Load("../../tests/config.toml")
+	b.SetBytes(largeInputSize)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		p.Extract(&repeatReader{text: text, n: largeInputSize}, stream)
 	}
 }
 
+func BenchmarkParseLarge(b *testing.B) {
+	benchmarkLarge(b, false)
+}
+
+func BenchmarkParseLargeStream(b *testing.B) {
+	benchmarkLarge(b, true)
+}
+
 func TestPluck0(t *testing.T) {
 	p, _ := New()
 	p.Verbose(false)
-	err := p.Load("test/config.toml")
+	err := p.Load("../../tests/config.toml")
 	if err != nil {
 		t.Error(err)
 	}
-	err = p.PluckFile("test/test.txt")
+	err = p.PluckFile("../../tests/test.txt")
 	if err != nil {
 		t.Error(err)
 	}
@@ -42,7 +102,7 @@ func TestPluck1(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	err = p.Load("test/config.toml")
+	err = p.Load("../../tests/config.toml")
 	if err != nil {
 		t.Error(err)
 	}
@@ -52,7 +112,7 @@ func TestPluck1(t *testing.T) {
 	assert.Equal(t, "options", p.pluckers[1].config.Name)
 	assert.Equal(t, "songs", p.pluckers[2].config.Name)
 
-	p.PluckFile("test/test.txt")
+	p.PluckFile("../../tests/test.txt")
 	assert.Equal(t, `{
     "0": "Category Archives: Song of the Day Podcast",
     "options": [
@@ -75,12 +135,12 @@ func TestPluck1(t *testing.T) {
 }`, p.ResultJSON(true))
 
 	p, _ = New()
-	p.Load("test/food.toml")
+	p.Load("../../tests/food.toml")
 	p.PluckURL("http://www.foodnetwork.com/recipes/food-network-kitchen/15-minute-shrimp-tacos-with-spicy-chipotle-slaw-3676441")
 	assert.Equal(t, `15-Minute Shrimp Tacos with Spicy Chipotle Slaw Recipe | Food Network Kitchen | Food Network`, p.Result()["title"])
 
 	p, _ = New()
-	p.Add(Config{
+	p.Add(config.Config{
 		Activators:  []string{"X", "Y"},
 		Deactivator: "Z",
 	})
@@ -94,7 +154,7 @@ func TestPluck1Stream(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	err = p.Load("test/config.toml")
+	err = p.Load("../../tests/config.toml")
 	if err != nil {
 		t.Error(err)
 	}
@@ -104,7 +164,7 @@ func TestPluck1Stream(t *testing.T) {
 	assert.Equal(t, "options", p.pluckers[1].config.Name)
 	assert.Equal(t, "songs", p.pluckers[2].config.Name)
 
-	p.PluckFile("test/test.txt", true)
+	p.PluckFile("../../tests/test.txt", true)
 	assert.Equal(t, `{
     "0": "Category Archives: Song of the Day Podcast",
     "options": [
@@ -134,12 +194,12 @@ func TestPluck2(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	err = p.Load("test/config2.toml")
+	err = p.Load("../../tests/config2.toml")
 	if err != nil {
 		t.Error(err)
 	}
 
-	p.PluckFile("test/test.txt")
+	p.PluckFile("../../tests/test.txt")
 	assert.Equal(t, `{
     "0": "Category Archives: Song of the Day Podcast",
     "options": [
@@ -163,12 +223,12 @@ func TestPluckSongs(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	err = p.Load("test/song.toml")
+	err = p.Load("../../tests/song.toml")
 	if err != nil {
 		t.Error(err)
 	}
 
-	p.PluckFile("test/song.html")
+	p.PluckFile("../../tests/song.html")
 	assert.Equal(t, `{
     "songs": [
         "/music/The+War+on+Drugs/_/An+Ocean+in+Between+the+Waves",
@@ -185,7 +245,7 @@ func TestPluckSkipSection(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	p.Add(Config{
+	p.Add(config.Config{
 		Activators:  []string{"Section 2", "a", "href", `"`},
 		Permanent:   1,
 		Deactivator: `"`,
@@ -213,7 +273,7 @@ func TestPluckCutSection(t *testing.T) {
 	if err != nil {
 		t.Error(err)
 	}
-	p.Add(Config{
+	p.Add(config.Config{
 		Activators:  []string{"Section 2", "a", "href", `"`},
 		Permanent:   1,
 		Deactivator: `"`,
@@ -232,7 +292,7 @@ func TestPluckCutSection(t *testing.T) {
 	assert.Nil(t, err)
 	assert.Equal(t, `{"0":"link3"}`, p.ResultJSON())
 
-	assert.Equal(t, []Config{{
+	assert.Equal(t, []config.Config{{
 		Activators:  []string{"Section 2", "a", "href", `"`},
 		Permanent:   1,
 		Deactivator: `"`,
@@ -242,3 +302,191 @@ func TestPluckCutSection(t *testing.T) {
 		Maximum:     6,
 	}}, p.Configuration())
 }
+
+func TestPluckTwice(t *testing.T) {
+	p, _ := New()
+	p.Load("../../tests/config.toml")
+	p.PluckFile("../../tests/test.txt")
+	first := p.ResultJSON()
+	p.PluckFile("../../tests/test.txt", true)
+	assert.Equal(t, first, p.ResultJSON())
+
+	p.Reset()
+	assert.Nil(t, p.Result())
+	assert.Equal(t, "", p.ResultJSON())
+}
+
+func TestExtractConcurrent(t *testing.T) {
+	p, _ := New()
+	p.Add(config.Config{
+		Activators:  []string{"<b>"},
+		Deactivator: "</b>",
+	})
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			input := strings.Repeat(fmt.Sprintf("<b>%d</b>", i), i+1)
+			result, err := p.ExtractString(input, i%2 == 0)
+			assert.Nil(t, err)
+			assert.Equal(t, i+1, len(result.Get("0")))
+			for _, c := range result.Strings("0") {
+				assert.Equal(t, strconv.Itoa(i), c)
+			}
+		}(i)
+	}
+	wg.Wait()
+	assert.Nil(t, p.Result())
+}
+
+func TestLoadFromString(t *testing.T) {
+	p, _ := New()
+	err := p.LoadFromString(`
+[[pluck]]
+name = "prices"
+activators = ["<li>"]
+deactivator = "</li>"
+type = "float"
+unique = true
+
+[[pluck.transforms]]
+name = "trim"
+
+[[pluck]]
+name = "off"
+enabled = false
+activators = ["<li>"]
+deactivator = "</li>"
+
+[[pluck]]
+record = "<ul>"
+
+[[pluck.children]]
+name = "first"
+activators = ["<li>"]
+deactivator = "</li>"
+limit = 1
+
+[[pluck.children]]
+name = "skipped"
+enabled = false
+activators = ["<li>"]
+deactivator = "</li>"
+`)
+	assert.Nil(t, err)
+	assert.Nil(t, p.PluckString("<ul><li> 1.5 </li><li>2</li><li>1.5</li></ul>"))
+	assert.Equal(t, `{"1":[{"first":"1.5"}],"prices":[1.5,2]}`, p.ResultJSON())
+}
+
+func TestLoadFromStringErrors(t *testing.T) {
+	p, _ := New()
+	p.Add(config.Config{Name: "kept", Activators: []string{"<b>"}, Deactivator: "</b>"})
+
+	// unknown keys and invalid pluckers are all reported
+	err := p.LoadFromString(`
+[[pluck]]
+name = "title"
+activator = ["<title>"]
+deactivator = "<"
+limit = -3
+`)
+	assert.EqualError(t, err, `2 problems in configuration:
+	line 2: plucker title: invalid limit -3
+	line 4: unknown key 'pluck.activator'`)
+
+	// none of the pluckers is added if one cannot be
+	err = p.LoadFromString(`
+[[pluck]]
+name = "a"
+activators = ["<a>"]
+deactivator = "</a>"
+
+[[pluck]]
+name = "b"
+activators = ["<a>"]
+deactivator = "</a>"
+type = "number"
+`)
+	assert.NotNil(t, err)
+	assert.True(t, strings.HasPrefix(err.Error(), "line 7: problem adding plucker b"), err.Error())
+	assert.Nil(t, p.PluckString("<a>1</a><b>2</b>"))
+	assert.Equal(t, `{"kept":"2"}`, p.ResultJSON())
+
+	err = p.LoadFromString(`[[pluck]`)
+	assert.True(t, strings.HasPrefix(err.Error(), "problem parsing configuration"), err.Error())
+}
+
+func TestLoadFromReader(t *testing.T) {
+	p, _ := New()
+	assert.Nil(t, p.LoadFromReader(strings.NewReader(`
+pluck:
+- name: title
+  activators: [<title>]
+  deactivator: <
+`), "yaml"))
+	// the format is detected if not given
+	assert.Nil(t, p.LoadFromReader(strings.NewReader(`{"pluck": [{"name": "bold", "activators": ["<b>"], "deactivator": "</b>"}]}`), ""))
+	assert.Nil(t, p.PluckString("<title>Songs</title><b>a</b><b>b</b>"))
+	assert.Equal(t, `{"bold":["a","b"],"title":"Songs"}`, p.ResultJSON())
+
+	err := p.LoadFromReader(strings.NewReader(`{}`), "csv")
+	assert.EqualError(t, err, "unknown configuration format 'csv'")
+}
+
+func TestLoadFormats(t *testing.T) {
+	dir, err := ioutil.TempDir("", "pluck")
+	if !assert.Nil(t, err) {
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	for name, data := range map[string]string{
+		"config.yml":  "pluck:\n- name: bold\n  activators: [<b>]\n  deactivator: </b>\n",
+		"config.ini":  "[pluck]\nname = bold\nactivators = <b>\ndeactivator = </b>\n",
+		"config.xml":  "<configs><pluck><name>bold</name><activators>&lt;b&gt;</activators><deactivator>&lt;/b&gt;</deactivator></pluck></configs>",
+		"config.json": `{"pluck": [{"name": "bold", "activators": ["<b>"], "deactivator": "</b>"}]}`,
+		"config":      "[[pluck]]\nname = \"bold\"\nactivators = [\"<b>\"]\ndeactivator = \"</b>\"\n",
+	} {
+		f := filepath.Join(dir, name)
+		assert.Nil(t, ioutil.WriteFile(f, []byte(data), 0644))
+		p, _ := New()
+		assert.Nil(t, p.Load(f), name)
+		assert.Nil(t, p.PluckString("<b>a</b><b>b</b>"))
+		assert.Equal(t, `{"bold":["a","b"]}`, p.ResultJSON(), name)
+	}
+
+	f := filepath.Join(dir, "invalid.yaml")
+	assert.Nil(t, ioutil.WriteFile(f, []byte("pluck:\n- name: bold\n"), 0644))
+	p, _ := New()
+	assert.EqualError(t, p.Load(f), "problem loading config file "+f+": plucker bold: missing deactivator")
+}
+
+func TestLoadConfigsEnabled(t *testing.T) {
+	disabled := false
+	p, _ := New()
+	assert.Nil(t, p.LoadConfigs(&config.Configs{Pluck: []config.Config{
+		{Name: "b", Activators: []string{"<b>"}, Deactivator: "</b>"},
+		{Name: "i", Activators: []string{"<i>"}, Deactivator: "</i>", Enabled: &disabled},
+	}}))
+	assert.Nil(t, p.PluckString("<b>bold</b><i>italic</i>"))
+	assert.Equal(t, `{"b":"bold"}`, p.ResultJSON())
+}
+
+func TestLoadKeepsVerbose(t *testing.T) {
+	defer log.SetLevel(log.GetLevel())
+	p, _ := New()
+	p.Verbose(true)
+	assert.Nil(t, p.LoadFromString(`
+[[pluck]]
+name = "items"
+record = "<li>"
+
+[[pluck.children]]
+name = "bold"
+activators = ["<b>"]
+deactivator = "</b>"
+`))
+	assert.Nil(t, p.Add(config.Config{Record: "<p>", Children: []config.Config{{Activators: []string{"<i>"}, Deactivator: "</i>"}}}))
+	assert.Equal(t, log.DebugLevel, log.GetLevel())
+}
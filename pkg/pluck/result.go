@@ -0,0 +1,238 @@
+package pluck
+
+import (
+	"encoding/json"
+
+	// internal
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+// Capture is an occurrence plucked from the input. A sanitized
+// capture spans all the input bytes its text comes from.
+type Capture struct {
+	Plucker string `json:"plucker"` // name of the plucker
+	Text    string `json:"text"`    // captured text
+	Offset  int    `json:"offset"`  // byte offset of the capture in the input
+	Length  int    `json:"length"`  // length in bytes of the capture in the input
+	End     int    `json:"end"`     // byte offset following the capture
+	Line    int    `json:"line"`    // line of the first byte, starting at 1
+	Column  int    `json:"column"`  // column in bytes of the first byte, starting at 1
+	// Truncated is set when the capture exceeded the capture limit of
+	// a plucker using the "truncate" overflow mode, Text then holding
+	// its beginning only
+	Truncated bool `json:"truncated,omitempty"`
+	// Value holds the text converted to the type of the plucker: an
+	// int64, a float64, a bool, a time.Time or the absolute URL as a
+	// string. It is nil for untyped pluckers and failed conversions.
+	Value interface{} `json:"value,omitempty"`
+	// Children holds the captures of the child pluckers in this capture
+	Children *Result `json:"children,omitempty"`
+	// Error describes the transform or the conversion which failed on
+	// this capture, Text then holding the value given to it
+	Error string `json:"error,omitempty"`
+}
+
+// Result holds the captures of each plucker after plucking an input,
+// in the order of the pluckers and of the input.
+type Result struct {
+	names    []string
+	captures [][]Capture
+	nested   []bool // the plucker has children
+	typed    []bool // the plucker converts its captures
+	skipped  []int  // captures skipped for exceeding the capture limit
+}
+
+// newResult gathers the captures of the pluckers.
+func newResult(states []*unitState) *Result {
+	r := &Result{
+		names:    make([]string, len(states)),
+		captures: make([][]Capture, len(states)),
+		nested:   make([]bool, len(states)),
+		typed:    make([]bool, len(states)),
+		skipped:  make([]int, len(states)),
+	}
+	for i, s := range states {
+		r.names[i] = s.unit.config.Name
+		r.captures[i] = s.captured
+		r.nested[i] = s.unit.children != nil
+		r.typed[i] = s.unit.valueType != config.TYPE_STRING
+		r.skipped[i] = s.numSkipped
+	}
+	return r
+}
+
+// Names returns the names of the pluckers, in the order they were added.
+func (r *Result) Names() []string {
+	if r == nil {
+		return nil
+	}
+	return r.names
+}
+
+// Get returns the captures of the plucker with the given name. When
+// several pluckers have the same name, the last one wins, as in Map.
+func (r *Result) Get(name string) []Capture {
+	if r == nil {
+		return nil
+	}
+	for i := len(r.names) - 1; i >= 0; i-- {
+		if r.names[i] == name {
+			return r.captures[i]
+		}
+	}
+	return nil
+}
+
+// Skipped returns the number of captures of the plucker with the given
+// name which were dropped for exceeding its capture limit, using the
+// "skip" overflow mode. When several pluckers have the same name, the
+// last one wins, as in Map.
+func (r *Result) Skipped(name string) int {
+	if r == nil {
+		return 0
+	}
+	for i := len(r.names) - 1; i >= 0; i-- {
+		if r.names[i] == name {
+			return r.skipped[i]
+		}
+	}
+	return 0
+}
+
+// Strings returns the texts captured by the plucker with the given name.
+func (r *Result) Strings(name string) []string {
+	captures := r.Get(name)
+	texts := make([]string, len(captures))
+	for i, c := range captures {
+		texts[i] = c.Text
+	}
+	return texts
+}
+
+// First returns the first text captured by the plucker with the
+// given name, and false if it did not capture anything.
+func (r *Result) First(name string) (string, bool) {
+	captures := r.Get(name)
+	if len(captures) == 0 {
+		return "", false
+	}
+	return captures[0].Text, true
+}
+
+// Map returns the result as a map from the name of each plucker to
+// "" if nothing was captured, a string for a single capture and
+// a slice of strings for several captures. A plucker with children
+// gives a slice with an object per capture (see Records), and a
+// typed plucker gives nil, a value or a slice of values instead,
+// a failed conversion giving nil.
+func (r *Result) Map() map[string]interface{} {
+	if r == nil {
+		return nil
+	}
+	m := make(map[string]interface{})
+	for i, name := range r.names {
+		m[name] = r.value(i, "")
+	}
+	return m
+}
+
+// Records returns an object per capture of the plucker with the given
+// name, holding the captures of its children: each child gives nil if
+// it captured nothing, a string for a single capture and a slice of
+// strings for several captures, or a slice of objects if it has
+// children itself. Typed children give values instead of strings.
+func (r *Result) Records(name string) []map[string]interface{} {
+	if r == nil {
+		return nil
+	}
+	for i := len(r.names) - 1; i >= 0; i-- {
+		if r.names[i] == name {
+			return r.records(i)
+		}
+	}
+	return nil
+}
+
+func (r *Result) records(i int) []map[string]interface{} {
+	records := make([]map[string]interface{}, len(r.captures[i]))
+	for j, c := range r.captures[i] {
+		records[j] = c.Children.record()
+	}
+	return records
+}
+
+// record returns the captures of child pluckers as an object.
+func (r *Result) record() map[string]interface{} {
+	m := make(map[string]interface{})
+	if r == nil {
+		return m
+	}
+	for i, name := range r.names {
+		m[name] = r.value(i, nil)
+	}
+	return m
+}
+
+// value returns the captures of the i-th plucker as in Map,
+// with the given value if nothing was captured.
+func (r *Result) value(i int, empty interface{}) interface{} {
+	captures := r.captures[i]
+	switch {
+	case r.nested[i]:
+		return r.records(i)
+	case len(captures) == 0 && r.typed[i]:
+		return nil
+	case len(captures) == 0:
+		return empty
+	case len(captures) == 1 && r.typed[i]:
+		return captures[0].Value
+	case len(captures) == 1:
+		return captures[0].Text
+	case r.typed[i]:
+		values := make([]interface{}, len(captures))
+		for j, c := range captures {
+			values[j] = c.Value
+		}
+		return values
+	}
+	texts := make([]string, len(captures))
+	for j, c := range captures {
+		texts[j] = c.Text
+	}
+	return texts
+}
+
+// MarshalJSON formats the result as its CaptureMap.
+func (r *Result) MarshalJSON() ([]byte, error) {
+	return json.Marshal(r.CaptureMap())
+}
+
+// CaptureMap returns the result as a map from the name
+// of each plucker to the list of its captures.
+func (r *Result) CaptureMap() map[string]interface{} {
+	if r == nil {
+		return nil
+	}
+	m := make(map[string]interface{})
+	for i, name := range r.names {
+		captures := r.captures[i]
+		if captures == nil {
+			captures = []Capture{}
+		}
+		m[name] = captures
+	}
+	return m
+}
+
+// JSON returns the result, formatted as JSON.
+// If their are no results, it returns an empty string.
+func (r *Result) JSON(indent ...bool) string {
+	return resultJSON(r.Map(), len(indent) > 0 && indent[0])
+}
+
+// PositionsJSON returns the result formatted as JSON, each capture
+// being an object with its text and its position in the input.
+// If their are no results, it returns an empty string.
+func (r *Result) PositionsJSON(indent ...bool) string {
+	return resultJSON(r.CaptureMap(), len(indent) > 0 && indent[0])
+}
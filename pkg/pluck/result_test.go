@@ -0,0 +1,179 @@
+package pluck
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+func TestResultCaptures(t *testing.T) {
+	s := "<b> one </b><i>two</i><b>three</b>"
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		p.Add(config.Config{Name: "bold", Activators: []string{"<b>"}, Deactivator: "</b>"})
+		p.Add(config.Config{Name: "italic", Activators: []string{"<i>"}, Deactivator: "</i>"})
+		p.Add(config.Config{Name: "none", Activators: []string{"<u>"}, Deactivator: "</u>"})
+		assert.Nil(t, p.PluckString(s, stream))
+		r := p.TypedResult()
+		assert.Equal(t, []string{"bold", "italic", "none"}, r.Names())
+		assert.Equal(t, []Capture{
+			{Plucker: "bold", Text: "one", Offset: 4, Length: 3, End: 7, Line: 1, Column: 5},
+			{Plucker: "bold", Text: "three", Offset: 25, Length: 5, End: 30, Line: 1, Column: 26},
+		}, r.Get("bold"), "stream %v", stream)
+		assert.Equal(t, "three", s[25:30])
+		assert.Equal(t, []string{"two"}, r.Strings("italic"))
+		first, ok := r.First("italic")
+		assert.Equal(t, "two", first)
+		assert.True(t, ok)
+		_, ok = r.First("none")
+		assert.False(t, ok)
+		assert.Equal(t, []string{}, r.Strings("none"))
+		assert.Nil(t, r.Get("missing"))
+		assert.Equal(t, map[string]interface{}{"bold": []string{"one", "three"}, "italic": "two", "none": ""}, p.Result())
+	}
+}
+
+func TestResultOffsets(t *testing.T) {
+	// split pieces have their own position
+	s := "tags: go,  pluck ,parser\n"
+	p, _ := New()
+	p.Add(config.Config{
+		Activators:  []string{"tags:"},
+		Deactivator: "\n",
+		Match:       config.Match{Separator: ",", Split: true},
+	})
+	p.PluckString(s)
+	captures := p.TypedResult().Get("0")
+	if assert.Equal(t, 3, len(captures)) {
+		for _, c := range captures {
+			assert.Equal(t, c.Text, s[c.Offset:c.Offset+c.Length])
+		}
+	}
+
+	// a sanitized capture spans the input it comes from
+	s = "<p> <b>bold</b> text</p>"
+	p, _ = New()
+	p.Add(config.Config{Activators: []string{"<p>"}, Deactivator: "</p>", Sanitize: true})
+	p.PluckString(s)
+	assert.Equal(t, []Capture{{Plucker: "0", Text: "bold text", Offset: 3, Length: 17, End: 20, Line: 1, Column: 4}}, p.TypedResult().Get("0"))
+
+	// a truncated capture spans the whole input it comes from
+	s = "<p> abcdefgh</p>"
+	p, _ = New()
+	p.Add(config.Config{Activators: []string{"<p>"}, Deactivator: "</p>", CaptureLimit: 4})
+	p.PluckString(s)
+	assert.Equal(t, []Capture{{Plucker: "0", Text: "abc", Offset: 4, Length: 8, End: 12, Line: 1, Column: 5, Truncated: true}}, p.TypedResult().Get("0"))
+}
+
+func TestResultLines(t *testing.T) {
+	s := "<ul>\n  <li>\n   one</li>\n  <li>two,\n three</li>\n</ul>"
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		p.Add(config.Config{
+			Activators:  []string{"<li>"},
+			Deactivator: "</li>",
+			Match:       config.Match{Separator: ",", Split: true},
+		})
+		p.PluckString(s, stream)
+		captures := p.TypedResult().Get("0")
+		if assert.Equal(t, 3, len(captures)) {
+			for i, expected := range [][2]int{{3, 4}, {4, 7}, {5, 2}} {
+				assert.Equal(t, expected, [2]int{captures[i].Line, captures[i].Column}, "%q, stream %v", captures[i].Text, stream)
+				assert.Equal(t, captures[i].Text, s[captures[i].Offset:captures[i].End])
+			}
+		}
+	}
+}
+
+func TestResultPositionsJSON(t *testing.T) {
+	p, _ := New()
+	p.Add(config.Config{Activators: []string{"<b>"}, Deactivator: "</b>"})
+	p.Add(config.Config{Name: "none", Activators: []string{"<u>"}, Deactivator: "</u>"})
+	p.PluckString("a\n<b>b</b>")
+	assert.Equal(t, `{"0":"b","none":""}`, p.ResultJSON())
+	p.Positions(true)
+	assert.Equal(t, `{"0":[{"plucker":"0","text":"b","offset":5,"length":1,"end":6,"line":2,"column":4}],"none":[]}`, p.ResultJSON())
+	p.PluckString("nothing")
+	assert.Equal(t, "", p.ResultJSON())
+}
+
+func TestResultNil(t *testing.T) {
+	var r *Result
+	assert.Nil(t, r.Names())
+	assert.Nil(t, r.Get("0"))
+	assert.Nil(t, r.Map())
+	p, _ := New()
+	assert.Nil(t, p.TypedResult())
+}
+
+const childrenHTML = `<ul>
+<li><a href="/one">One</a> <i>rock</i></li>
+<li><a href="/two">Two</a></li>
+<li><a href="/three">Three</a> <i>pop</i> <i>jazz</i></li>
+</ul>`
+
+func TestResultChildren(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		err := p.Add(config.Config{
+			Name:        "songs",
+			Activators:  []string{"<li>"},
+			Deactivator: "</li>",
+			Sanitize:    true,
+			Children: []config.Config{
+				{Name: "title", Activators: []string{"<a", ">"}, Deactivator: "<"},
+				{Name: "href", Activators: []string{`href="`}, Deactivator: `"`},
+				{Name: "genres", Activators: []string{"<i>"}, Deactivator: "</i>"},
+			},
+		})
+		assert.Nil(t, err)
+		assert.Nil(t, p.PluckString(childrenHTML, stream))
+		assert.Equal(t, `{"songs":[{"genres":"rock","href":"/one","title":"One"},{"genres":null,"href":"/two","title":"Two"},{"genres":["pop","jazz"],"href":"/three","title":"Three"}]}`, p.ResultJSON(), "stream %v", stream)
+
+		// the parent capture is still sanitized
+		r := p.TypedResult()
+		assert.Equal(t, []string{"One rock", "Two", "Three pop jazz"}, r.Strings("songs"))
+		// the children are located in the input
+		c := r.Get("songs")[2].Children.Get("genres")[1]
+		assert.Equal(t, "jazz", childrenHTML[c.Offset:c.End])
+		assert.Equal(t, 4, c.Line)
+		assert.Equal(t, r.Records("songs"), p.Result()["songs"])
+	}
+}
+
+func TestResultChildrenLoad(t *testing.T) {
+	p, _ := New()
+	err := p.LoadFromString(`
+[[pluck]]
+name = "songs"
+activators = ["<ul>"]
+deactivator = "</ul>"
+
+[[pluck.children]]
+name = "items"
+activators = ["<li>"]
+deactivator = "</li>"
+
+[[pluck.children.children]]
+name = "href"
+activators = ['href="']
+deactivator = '"'
+`)
+	assert.Nil(t, err)
+	p.PluckString(childrenHTML)
+	assert.Equal(t, `{"songs":[{"items":[{"href":"/one"},{"href":"/two"},{"href":"/three"}]}]}`, p.ResultJSON())
+
+	p.Positions(true)
+	assert.Contains(t, p.ResultJSON(), `"children":{"href":[{"plucker":"href","text":"/two","offset":`)
+
+	p, _ = New()
+	err = p.Add(config.Config{
+		Name:        "songs",
+		Activators:  []string{"<li>"},
+		Deactivator: "</li>",
+		Children:    []config.Config{{Name: "bad", Activators: []string{"re:("}, Deactivator: "<"}},
+	})
+	assert.Contains(t, err.Error(), "problem adding plucker songs: problem adding plucker bad: activator 0")
+}
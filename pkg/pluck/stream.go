@@ -0,0 +1,55 @@
+package pluck
+
+import (
+	"context"
+	"io"
+)
+
+// PluckStreamFunc streams the reader in chunks of 64KiB, like
+// PluckStream, but calls fn with the name of the plucker and the
+// text of each capture instead of storing it, so that the memory
+// used does not grow with the input. The captures ended in a chunk
+// are passed to fn in input order once every plucker went through
+// the chunk, and the ones still open at the end of the input once
+// it is over.
+// Plucking stops at the first error returned by fn, which is
+// then returned. The result of the plucker is left unchanged.
+func (p *Plucker) PluckStreamFunc(r io.Reader, fn func(name string, capture []byte) error) error {
+	return p.PluckStreamFuncContext(context.Background(), r, fn)
+}
+
+// PluckStreamFuncContext is like PluckStreamFunc but stops
+// as soon as the context is done.
+func (p *Plucker) PluckStreamFuncContext(ctx context.Context, r io.Reader, fn func(name string, capture []byte) error) error {
+	_, err := p.extractStream(ctx, &contextReader{ctx: ctx, r: r}, nil, func(c Capture) error {
+		return fn(c.Plucker, []byte(c.Text))
+	})
+	return err
+}
+
+// PluckStreamChan streams the reader in chunks of 64KiB in a new
+// goroutine and sends each capture on the returned channel. As for
+// PluckStreamFunc, the captures ended in a chunk are sent in input
+// order once every plucker went through the chunk. The channel of captures is closed once plucking stops, after
+// which the error channel yields the error met, if any. Cancelling
+// the context stops plucking early.
+func (p *Plucker) PluckStreamChan(ctx context.Context, r io.Reader) (<-chan Capture, <-chan error) {
+	captures := make(chan Capture)
+	errc := make(chan error, 1)
+	go func() {
+		defer close(errc)
+		_, err := p.extractStream(ctx, &contextReader{ctx: ctx, r: r}, nil, func(c Capture) error {
+			select {
+			case captures <- c:
+				return nil
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		})
+		close(captures)
+		if err != nil {
+			errc <- err
+		}
+	}()
+	return captures, errc
+}
@@ -0,0 +1,120 @@
+package pluck
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+func TestPluckStreamFunc(t *testing.T) {
+	p, _ := New()
+	p.Add(config.Config{Name: "bold", Activators: []string{"<b>"}, Deactivator: "</b>"})
+	p.Add(config.Config{Name: "italic", Activators: []string{"<i>"}, Deactivator: "</i>", Limit: 1})
+	var got []string
+	err := p.PluckStreamFunc(strings.NewReader("<b>a</b><i>b</i><b>c</b><i>d</i>"), func(name string, capture []byte) error {
+		got = append(got, name+":"+string(capture))
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"bold:a", "italic:b", "bold:c"}, got)
+	assert.Nil(t, p.Result())
+}
+
+func TestPluckStreamFuncStop(t *testing.T) {
+	p, _ := New()
+	p.Add(config.Config{Activators: []string{"<b>"}, Deactivator: "</b>"})
+	stop := errors.New("stop")
+	r := &countReader{r: strings.NewReader(strings.Repeat("<b>a</b>", 100000))}
+	n := 0
+	err := p.PluckStreamFunc(r, func(name string, capture []byte) error {
+		n++
+		if n == 3 {
+			return stop
+		}
+		return nil
+	})
+	assert.Equal(t, stop, err)
+	assert.Equal(t, 3, n)
+	// the rest of the input is not read
+	assert.True(t, r.n < 100000)
+}
+
+func TestPluckStreamChan(t *testing.T) {
+	p, _ := New()
+	p.Add(config.Config{Activators: []string{"<b>"}, Deactivator: "</b>"})
+	captures, errc := p.PluckStreamChan(context.Background(), strings.NewReader("<b>a</b>\n<b>b</b>"))
+	var got []Capture
+	for c := range captures {
+		got = append(got, c)
+	}
+	assert.Nil(t, <-errc)
+	assert.Equal(t, []Capture{
+		{Plucker: "0", Text: "a", Offset: 3, Length: 1, End: 4, Line: 1, Column: 4},
+		{Plucker: "0", Text: "b", Offset: 12, Length: 1, End: 13, Line: 2, Column: 4},
+	}, got)
+
+	// cancelling stops plucking
+	ctx, cancel := context.WithCancel(context.Background())
+	captures, errc = p.PluckStreamChan(ctx, strings.NewReader(strings.Repeat("<b>a</b>", 100000)))
+	<-captures
+	cancel()
+	for range captures {
+	}
+	assert.Equal(t, context.Canceled, <-errc)
+}
+
+func TestPluckNulBytes(t *testing.T) {
+	s := "\x00<b>a\x00b</b>\x00\x00<b>c</b>"
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		p.Add(config.Config{Activators: []string{"<b>"}, Deactivator: "</b>"})
+		p.PluckString(s, stream)
+		assert.Equal(t, []string{"a\x00b", "c"}, p.TypedResult().Strings("0"), "stream %v", stream)
+	}
+}
+
+func TestPluckStreamChunks(t *testing.T) {
+	// the activators, deactivators and captures overlap the chunks
+	item := "<item>" + strings.Repeat("x", 997) + "</item>\n"
+	s := strings.Repeat(item, 3*chunkSize/len(item))
+	p, _ := New()
+	p.Add(config.Config{Name: "item", Activators: []string{"<item>"}, Deactivator: "</item>"})
+	p.Add(config.Config{Name: "end", Activators: []string{"</item>"}, Deactivator: "<"})
+	parallel, err := p.ExtractString(s)
+	assert.Nil(t, err)
+	stream, err := p.ExtractString(s, true)
+	assert.Nil(t, err)
+	assert.Equal(t, len(s)/len(item), len(stream.Get("item")))
+	assert.Equal(t, parallel.Get("item"), stream.Get("item"))
+	assert.Equal(t, parallel.Get("end"), stream.Get("end"))
+	last := stream.Get("item")[len(stream.Get("item"))-1]
+	assert.Equal(t, len(s)/len(item), last.Line)
+
+	// the emitted captures of all the pluckers are in input order
+	var names []string
+	p.PluckStreamFunc(strings.NewReader(s), func(name string, capture []byte) error {
+		names = append(names, name)
+		return nil
+	})
+	for i := range names {
+		assert.Equal(t, []string{"item", "end"}[i%2], names[i])
+	}
+}
+
+// countReader counts the bytes read.
+type countReader struct {
+	r io.Reader
+	n int
+}
+
+func (r *countReader) Read(b []byte) (int, error) {
+	n, err := r.r.Read(b)
+	r.n += n
+	return n, err
+}
@@ -0,0 +1,45 @@
+package pluck
+
+import (
+	// external
+	"github.com/pkg/errors"
+
+	// internal
+	config "github.com/sniperkit/pluck/pkg/config"
+	transform "github.com/sniperkit/pluck/pkg/transform"
+)
+
+// namedTransform is a transform of a plucker with its name,
+// which prefixes its errors.
+type namedTransform struct {
+	name      string
+	transform transform.Transform
+}
+
+// compileTransforms creates the transforms of a plucker
+// and fills the corresponding field of the unit.
+func (u *pluckUnit) compileTransforms(c config.Config) error {
+	u.transforms = make([]namedTransform, len(c.Transforms))
+	for i, t := range c.Transforms {
+		tr, err := transform.New(t.Name, t.Args...)
+		if err != nil {
+			return errors.Wrapf(err, "transform %d", i)
+		}
+		u.transforms[i] = namedTransform{name: t.Name, transform: tr}
+	}
+	return nil
+}
+
+// transform applies the transforms of the unit in order to a captured
+// text. If a transform fails, the text it was given is returned with
+// the error, the following transforms being skipped.
+func (u *pluckUnit) transform(text string, env *transform.Env) (string, error) {
+	for _, t := range u.transforms {
+		value, err := t.transform.Apply(text, env)
+		if err != nil {
+			return text, errors.Wrap(err, "transform "+t.name)
+		}
+		text = value
+	}
+	return text, nil
+}
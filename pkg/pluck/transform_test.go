@@ -0,0 +1,147 @@
+package pluck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+	transform "github.com/sniperkit/pluck/pkg/transform"
+)
+
+func TestTransforms(t *testing.T) {
+	s := "<b>  Hello\n  World </b><b> 1,234.5 kg </b>"
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		assert.Nil(t, p.Add(config.Config{
+			Name:        "bold",
+			Activators:  []string{"<b>"},
+			Deactivator: "</b>",
+			Transforms: []config.Transform{
+				{Name: "collapse_whitespace"},
+				{Name: "lowercase"},
+				{Name: "regex_replace", Args: []string{`(\d),(\d)`, "$1$2"}},
+			},
+		}))
+		assert.Nil(t, p.PluckString(s, stream))
+		assert.Equal(t, []string{"hello world", "1234.5 kg"}, p.TypedResult().Strings("bold"))
+
+		// the positions are those of the capture in the input
+		c := p.TypedResult().Get("bold")[0]
+		assert.Equal(t, "Hello\n  World", s[c.Offset:c.End])
+	}
+}
+
+func TestTransformsError(t *testing.T) {
+	p, _ := New()
+	p.Add(config.Config{
+		Name:        "price",
+		Activators:  []string{"<i>"},
+		Deactivator: "</i>",
+		Transforms: []config.Transform{
+			{Name: "trim"},
+			{Name: "number"},
+			{Name: "uppercase"},
+		},
+	})
+	assert.Nil(t, p.PluckString("<i> 12 </i><i> free </i>"))
+	captures := p.TypedResult().Get("price")
+	if assert.Equal(t, 2, len(captures)) {
+		assert.Equal(t, "12", captures[0].Text)
+		assert.Equal(t, "", captures[0].Error)
+		// the value given to the failing transform is kept
+		assert.Equal(t, "free", captures[1].Text)
+		assert.Equal(t, "transform number: no number in 'free'", captures[1].Error)
+	}
+	p.Positions(true)
+	assert.Contains(t, p.ResultJSON(), `"error":"transform number: no number in 'free'"`)
+
+	p, _ = New()
+	err := p.Add(config.Config{
+		Name:        "price",
+		Activators:  []string{"<i>"},
+		Deactivator: "</i>",
+		Transforms:  []config.Transform{{Name: "trim"}, {Name: "missing"}},
+	})
+	assert.EqualError(t, err, "problem adding plucker price: transform 1: unknown transform 'missing'")
+}
+
+func TestTransformsLoad(t *testing.T) {
+	p, _ := New()
+	err := p.LoadFromString(`
+[[pluck]]
+name = "data"
+activators = ['data="']
+deactivator = '"'
+
+[[pluck.transforms]]
+name = "base64_decode"
+
+[[pluck.transforms]]
+name = "trim"
+args = ["!"]
+`)
+	assert.Nil(t, err)
+	p.PluckString(`<p data="IWhlbGxvIQ=="></p>`)
+	assert.Equal(t, `{"data":"hello"}`, p.ResultJSON())
+}
+
+func TestTransformsCustom(t *testing.T) {
+	transform.Register("test_initials", transform.Simple(func(s string) string {
+		var initials []string
+		for _, word := range strings.Fields(s) {
+			initials = append(initials, word[:1])
+		}
+		return strings.Join(initials, ".")
+	}))
+	p, _ := New()
+	p.Add(config.Config{
+		Activators:  []string{"<b>"},
+		Deactivator: "</b>",
+		Transforms:  []config.Transform{{Name: "test_initials"}},
+	})
+	p.PluckString("<b>John Ronald Reuel Tolkien</b>")
+	assert.Equal(t, `{"0":"J.R.R.T"}`, p.ResultJSON())
+}
+
+func TestTransformsURL(t *testing.T) {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/songs/", func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(childrenHTML))
+	})
+	mux.Handle("/old", http.RedirectHandler("/songs/list", http.StatusMovedPermanently))
+	ts := httptest.NewServer(mux)
+	defer ts.Close()
+
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		p.Add(config.Config{
+			Name:        "songs",
+			Activators:  []string{`<li><a href="`},
+			Deactivator: `"`,
+			Transforms:  []config.Transform{{Name: "url_resolve"}},
+		})
+		// the URLs are resolved against the final URL of the page
+		assert.Nil(t, p.PluckURL(ts.URL+"/old", stream))
+		assert.Equal(t, []string{ts.URL + "/one", ts.URL + "/two", ts.URL + "/three"}, p.TypedResult().Strings("songs"))
+	}
+
+	// children see the same base URL
+	p, _ := New()
+	p.Add(config.Config{
+		Name:        "songs",
+		Activators:  []string{"<ul>"},
+		Deactivator: "</ul>",
+		Children: []config.Config{{
+			Name:        "href",
+			Activators:  []string{`href="`},
+			Deactivator: `"`,
+			Transforms:  []config.Transform{{Name: "url_resolve"}},
+		}},
+	})
+	assert.Nil(t, p.PluckURL(ts.URL+"/songs/"))
+	assert.Equal(t, []string{ts.URL + "/one", ts.URL + "/two", ts.URL + "/three"}, p.TypedResult().Get("songs")[0].Children.Strings("href"))
+}
@@ -0,0 +1,29 @@
+package pluck
+
+import (
+	"strings"
+)
+
+// uniqueKey returns the text a capture is compared on to find
+// duplicates: with unique_normalized, its white space is collapsed
+// and it is in lower case.
+func (u *pluckUnit) uniqueKey(text string) string {
+	if u.config.UniqueNormalized {
+		return strings.ToLower(strings.Join(strings.Fields(text), " "))
+	}
+	return text
+}
+
+// isDuplicate reports whether a capture with the same text was
+// already kept by a unique plucker, remembering the text otherwise.
+func (s *unitState) isDuplicate(text string) bool {
+	if s.seen == nil {
+		return false
+	}
+	key := s.unit.uniqueKey(text)
+	if _, ok := s.seen[key]; ok {
+		return true
+	}
+	s.seen[key] = struct{}{}
+	return false
+}
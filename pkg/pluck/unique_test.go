@@ -0,0 +1,69 @@
+package pluck
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+const uniqueHTML = `<a href="/a">A</a> <a href="/b">B</a> <a href="/a">A</a>
+<a href="/c">C</a> <a href="/b">B</a> <a href="/d">D</a>`
+
+func TestUnique(t *testing.T) {
+	for _, stream := range []bool{false, true} {
+		p, _ := New()
+		p.Add(config.Config{Name: "all", Activators: []string{`href="`}, Deactivator: `"`})
+		p.Add(config.Config{Name: "unique", Activators: []string{`href="`}, Deactivator: `"`, Unique: true})
+		// the limit counts distinct captures
+		p.Add(config.Config{Name: "first3", Activators: []string{`href="`}, Deactivator: `"`, Unique: true, Limit: 3})
+		assert.Nil(t, p.PluckString(uniqueHTML, stream))
+		r := p.TypedResult()
+		assert.Equal(t, []string{"/a", "/b", "/a", "/c", "/b", "/d"}, r.Strings("all"))
+		assert.Equal(t, []string{"/a", "/b", "/c", "/d"}, r.Strings("unique"))
+		assert.Equal(t, []string{"/a", "/b", "/c"}, r.Strings("first3"))
+
+		// the first occurrence is kept
+		assert.Equal(t, 9, r.Get("unique")[0].Offset)
+		assert.Equal(t, 2, r.Get("unique")[2].Line)
+	}
+}
+
+func TestUniqueNormalized(t *testing.T) {
+	s := "<b>New  York</b><b>new york</b><b> NEW\nYORK </b><b>Boston</b><b>boston</b>"
+	p, _ := New()
+	p.Add(config.Config{Name: "exact", Activators: []string{"<b>"}, Deactivator: "</b>", Unique: true})
+	p.Add(config.Config{Name: "normalized", Activators: []string{"<b>"}, Deactivator: "</b>", UniqueNormalized: true, Limit: 2})
+	p.PluckString(s)
+	assert.Equal(t, []string{"New  York", "new york", "NEW\nYORK", "Boston", "boston"}, p.TypedResult().Strings("exact"))
+	assert.Equal(t, []string{"New  York", "Boston"}, p.TypedResult().Strings("normalized"))
+}
+
+func TestUniqueAfterTransforms(t *testing.T) {
+	// duplicates are found on the final text of the captures
+	p, _ := New()
+	err := p.LoadFromString(`
+[[pluck]]
+name = "tags"
+activators = ["<i>"]
+deactivator = "</i>"
+unique = true
+
+[pluck.match]
+separator = ","
+split = true
+
+[[pluck.transforms]]
+name = "lowercase"
+`)
+	assert.Nil(t, err)
+	var emitted []string
+	err = p.PluckStreamFunc(strings.NewReader("<i>Go,go,Rust</i><i>rust,Zig</i>"), func(name string, capture []byte) error {
+		emitted = append(emitted, string(capture))
+		return nil
+	})
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"go", "rust", "zig"}, emitted)
+}
@@ -0,0 +1,120 @@
+package pluck
+
+import (
+	"math"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	// external
+	"github.com/pkg/errors"
+
+	// internal
+	config "github.com/sniperkit/pluck/pkg/config"
+	transform "github.com/sniperkit/pluck/pkg/transform"
+)
+
+// compileType checks the type and the format of a plucker
+// and fills the corresponding fields of the unit.
+func (u *pluckUnit) compileType(c config.Config) error {
+	u.valueType = config.ValueType(c.Type)
+	switch u.valueType {
+	case "", config.TYPE_STRING:
+		u.valueType = config.TYPE_STRING
+	case config.TYPE_INT, config.TYPE_FLOAT:
+		switch c.Format {
+		case "", ".":
+			u.decimal = '.'
+		case ",":
+			u.decimal = ','
+		default:
+			return errors.Errorf("unknown decimal separator '%s'", c.Format)
+		}
+		return nil
+	case config.TYPE_DATE:
+		u.layout = c.Format
+		if u.layout == "" {
+			u.layout = time.RFC3339
+		}
+		return nil
+	case config.TYPE_BOOL, config.TYPE_URL:
+	default:
+		return errors.Errorf("unknown type '%s'", c.Type)
+	}
+	if c.Format != "" {
+		return errors.Errorf("format '%s' given for type %s, which has none", c.Format, u.valueType)
+	}
+	return nil
+}
+
+// convert returns a captured text converted to the type of the unit:
+// an int64, a float64, a bool, a time.Time or the absolute URL as a
+// string, resolved against the URL of the input if relative.
+func (u *pluckUnit) convert(text string, env *transform.Env) (interface{}, error) {
+	value, err := u.convertText(text, env)
+	if err != nil {
+		return nil, errors.Wrapf(err, "type %s", u.valueType)
+	}
+	return value, nil
+}
+
+func (u *pluckUnit) convertText(text string, env *transform.Env) (interface{}, error) {
+	switch u.valueType {
+	case config.TYPE_INT:
+		if n, err := strconv.ParseInt(strings.TrimSpace(text), 10, 64); err == nil {
+			return n, nil
+		}
+		number, err := transform.Number(text, u.decimal)
+		if err != nil {
+			return nil, err
+		}
+		n, err := strconv.ParseInt(number, 10, 64)
+		if err != nil {
+			return nil, errors.Errorf("'%s' is not an integer", text)
+		}
+		return n, nil
+	case config.TYPE_FLOAT:
+		// a plain number, but not "NaN" or "Inf" which JSON cannot hold
+		n, err := strconv.ParseFloat(strings.TrimSpace(text), 64)
+		if err == nil && u.decimal == '.' && !math.IsNaN(n) && !math.IsInf(n, 0) {
+			return n, nil
+		}
+		number, err := transform.Number(text, u.decimal)
+		if err != nil {
+			return nil, err
+		}
+		n, err = strconv.ParseFloat(number, 64)
+		if err != nil {
+			return nil, errors.Errorf("'%s' is not a number", text)
+		}
+		return n, nil
+	case config.TYPE_BOOL:
+		switch strings.ToLower(strings.TrimSpace(text)) {
+		case "true", "t", "yes", "y", "on", "1":
+			return true, nil
+		case "false", "f", "no", "n", "off", "0":
+			return false, nil
+		}
+		return nil, errors.Errorf("'%s' is not a boolean", text)
+	case config.TYPE_DATE:
+		t, err := time.Parse(u.layout, strings.TrimSpace(text))
+		if err != nil {
+			return nil, errors.Errorf("'%s' does not match the layout '%s'", text, u.layout)
+		}
+		return t, nil
+	case config.TYPE_URL:
+		ref, err := url.Parse(strings.TrimSpace(text))
+		if err != nil {
+			return nil, errors.Errorf("'%s' is not a URL", text)
+		}
+		if env != nil && env.BaseURL != nil {
+			ref = env.BaseURL.ResolveReference(ref)
+		}
+		if !ref.IsAbs() {
+			return nil, errors.Errorf("no base URL to resolve '%s'", text)
+		}
+		return ref.String(), nil
+	}
+	return text, nil
+}
@@ -0,0 +1,161 @@
+package pluck
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	config "github.com/sniperkit/pluck/pkg/config"
+)
+
+func TestTypes(t *testing.T) {
+	for _, test := range []struct {
+		typ    string
+		format string
+		text   string
+		value  interface{}
+	}{
+		{"int", "", "42", int64(42)},
+		{"int", "", " 1,234 items", int64(1234)},
+		{"int", ",", "-1.234.567", int64(-1234567)},
+		{"float", "", "$ 1,234.50", 1234.5},
+		{"float", ",", "1.234,5 €", 1234.5},
+		{"float", ".", "0.25", 0.25},
+		{"float", "", "1.5e3", 1500.0},
+		{"float", "", "about 2.5E-2 kg", 0.025},
+		{"float", ",", "1,5e3", 1500.0},
+		{"int", "", "-7", int64(-7)},
+		{"bool", "", " Yes ", true},
+		{"bool", "", "off", false},
+		{"bool", "", "TRUE", true},
+		{"date", "", "2018-03-04T05:06:07Z", time.Date(2018, 3, 4, 5, 6, 7, 0, time.UTC)},
+		{"date", "02/01/2006", "04/03/2018", time.Date(2018, 3, 4, 0, 0, 0, 0, time.UTC)},
+		{"url", "", "https://example.com/a?b=c", "https://example.com/a?b=c"},
+		{"string", "", "text", nil},
+		{"", "", "text", nil},
+	} {
+		p, _ := New()
+		err := p.Add(config.Config{Activators: []string{"<b>"}, Deactivator: "</b>", Type: test.typ, Format: test.format})
+		if !assert.Nil(t, err, test.typ) {
+			continue
+		}
+		p.PluckString("<b>" + test.text + "</b>")
+		captures := p.TypedResult().Get("0")
+		if assert.Equal(t, 1, len(captures), test.text) {
+			assert.Equal(t, strings.TrimSpace(test.text), captures[0].Text)
+			assert.Equal(t, test.value, captures[0].Value, "%s %q", test.typ, test.text)
+			assert.Equal(t, "", captures[0].Error)
+		}
+	}
+}
+
+func TestTypesErrors(t *testing.T) {
+	for _, test := range []struct {
+		typ    string
+		format string
+		text   string
+		err    string
+	}{
+		{"int", "", "none", "type int: no number in 'none'"},
+		{"int", "", "1.5", "type int: '1.5' is not an integer"},
+		{"int", "", "1e5", "type int: '1e5' is not an integer"},
+		{"float", "", "1.2.3", "type float: '1.2.3' is not a number"},
+		{"float", "", "NaN", "type float: no number in 'NaN'"},
+		{"bool", "", "maybe", "type bool: 'maybe' is not a boolean"},
+		{"date", "2006-01-02", "March 4", "type date: 'March 4' does not match the layout '2006-01-02'"},
+		{"url", "", "/relative", "type url: no base URL to resolve '/relative'"},
+	} {
+		p, _ := New()
+		p.Add(config.Config{Activators: []string{"<b>"}, Deactivator: "</b>", Type: test.typ, Format: test.format})
+		assert.Nil(t, p.PluckString("<b>"+test.text+"</b><b>1</b>"))
+		captures := p.TypedResult().Get("0")
+		if assert.Equal(t, 2, len(captures), test.text) {
+			assert.Equal(t, test.text, captures[0].Text)
+			assert.Nil(t, captures[0].Value)
+			assert.Equal(t, test.err, captures[0].Error)
+		}
+	}
+
+	for _, test := range []struct {
+		typ    string
+		format string
+		err    string
+	}{
+		{"number", "", "problem adding plucker 0: unknown type 'number'"},
+		{"float", ";", "problem adding plucker 0: unknown decimal separator ';'"},
+		{"bool", "yes", "problem adding plucker 0: format 'yes' given for type bool, which has none"},
+		{"", "2006", "problem adding plucker 0: format '2006' given for type string, which has none"},
+	} {
+		p, _ := New()
+		err := p.Add(config.Config{Activators: []string{"<b>"}, Deactivator: "</b>", Type: test.typ, Format: test.format})
+		assert.EqualError(t, err, test.err)
+	}
+}
+
+func TestTypesJSON(t *testing.T) {
+	p, _ := New()
+	err := p.LoadFromString(`
+[[pluck]]
+name = "price"
+activators = ["<i>"]
+deactivator = "</i>"
+type = "float"
+format = ","
+
+[[pluck]]
+name = "stock"
+activators = ["<b>"]
+deactivator = "</b>"
+type = "int"
+
+[[pluck]]
+name = "date"
+activators = ["<time>"]
+deactivator = "</time>"
+type = "date"
+format = "2006-01-02"
+
+[[pluck]]
+name = "available"
+activators = ["<u>"]
+deactivator = "</u>"
+type = "bool"
+`)
+	assert.Nil(t, err)
+	p.PluckString("<i>12,50 €</i><i>free</i><b>3</b><time>2018-03-04</time>")
+	assert.Equal(t, `{"available":null,"date":"2018-03-04T00:00:00Z","price":[12.5,null],"stock":3}`, p.ResultJSON())
+
+	p.Positions(true)
+	assert.Contains(t, p.ResultJSON(), `"text":"12,50 €","offset":3,"length":9,"end":12,"line":1,"column":4,"value":12.5}`)
+	assert.Contains(t, p.ResultJSON(), `"text":"free","offset":19,"length":4,"end":23,"line":1,"column":20,"error":"type float: no number in 'free'"}`)
+}
+
+func TestTypesRecords(t *testing.T) {
+	p, _ := New()
+	p.Add(config.Config{
+		Name:   "items",
+		Record: "<div>",
+		Children: []config.Config{
+			{Name: "name", Activators: []string{"<b>"}, Deactivator: "</b>"},
+			{Name: "price", Activators: []string{"<i>"}, Deactivator: "</i>", Type: "float"},
+		},
+	})
+	p.PluckString("<div><b>Apple</b><i>1.20</i><div><b>Cherry</b>")
+	assert.Equal(t, `{"items":[{"name":"Apple","price":1.2},{"name":"Cherry","price":null}]}`, p.ResultJSON())
+}
+
+func TestTypesURL(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`<a href="../about.html">About</a>`))
+	}))
+	defer ts.Close()
+
+	p, _ := New()
+	p.Add(config.Config{Activators: []string{`href="`}, Deactivator: `"`, Type: "url"})
+	assert.Nil(t, p.PluckURL(ts.URL+"/songs/list"))
+	assert.Equal(t, ts.URL+"/about.html", p.Result()["0"])
+}
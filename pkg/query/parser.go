@@ -0,0 +1,199 @@
+package query
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+type tokenKind int
+
+const (
+	tokEOF tokenKind = iota
+	tokTerm
+	tokOr
+	tokNot
+	tokMaybe
+	tokOpen
+	tokClose
+)
+
+type token struct {
+	kind   tokenKind
+	text   string
+	offset int
+}
+
+func (t token) String() string {
+	switch t.kind {
+	case tokEOF:
+		return "end of query"
+	case tokTerm:
+		return fmt.Sprintf("term %q", t.text)
+	case tokMaybe:
+		return "'MAYBE'"
+	}
+	return "'" + t.text + "'"
+}
+
+type parser struct {
+	query  string
+	tokens []token
+	pos    int
+}
+
+func (p *parser) errorf(t token, format string, args ...interface{}) error {
+	return &SyntaxError{Query: p.query, Offset: t.offset, Msg: fmt.Sprintf(format, args...)}
+}
+
+// scan splits the query into tokens.
+func (p *parser) scan() error {
+	q := p.query
+	for i := 0; i < len(q); {
+		r, size := utf8.DecodeRuneInString(q[i:])
+		switch {
+		case unicode.IsSpace(r):
+			i += size
+		case r == '(':
+			p.tokens = append(p.tokens, token{tokOpen, "(", i})
+			i++
+		case r == ')':
+			p.tokens = append(p.tokens, token{tokClose, ")", i})
+			i++
+		case r == '|':
+			p.tokens = append(p.tokens, token{tokOr, "|", i})
+			i++
+		case r == '-' || r == '!':
+			p.tokens = append(p.tokens, token{tokNot, string(r), i})
+			i++
+		case r == '"':
+			end := strings.IndexByte(q[i+1:], '"')
+			if end < 0 {
+				return &SyntaxError{Query: q, Offset: i, Msg: "unterminated phrase"}
+			}
+			if end == 0 {
+				return &SyntaxError{Query: q, Offset: i, Msg: "empty phrase"}
+			}
+			p.tokens = append(p.tokens, token{tokTerm, q[i+1 : i+1+end], i})
+			i += end + 2
+		default:
+			// a word runs until a space or a special character,
+			// a '-' or '!' inside a word belongs to the word
+			j := i
+			for j < len(q) {
+				r, size := utf8.DecodeRuneInString(q[j:])
+				if unicode.IsSpace(r) || strings.ContainsRune(`()|"`, r) {
+					break
+				}
+				j += size
+			}
+			kind := tokTerm
+			if q[i:j] == "MAYBE" {
+				kind = tokMaybe
+			}
+			p.tokens = append(p.tokens, token{kind, q[i:j], i})
+			i = j
+		}
+	}
+	p.tokens = append(p.tokens, token{tokEOF, "", len(q)})
+	return nil
+}
+
+func (p *parser) peek() token { return p.tokens[p.pos] }
+
+func (p *parser) next() token {
+	t := p.tokens[p.pos]
+	if t.kind != tokEOF {
+		p.pos++
+	}
+	return t
+}
+
+// parseAnd parses a sequence of implicitly AND-ed expressions.
+func (p *parser) parseAnd() (Expr, error) {
+	var xs and
+	for {
+		if k := p.peek().kind; k == tokEOF || k == tokClose {
+			break
+		}
+		x, err := p.parseMaybe()
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, x)
+	}
+	if len(xs) == 0 {
+		return nil, p.errorf(p.peek(), "expected a term, found %s", p.peek())
+	}
+	if len(xs) == 1 {
+		return xs[0], nil
+	}
+	return xs, nil
+}
+
+func (p *parser) parseMaybe() (Expr, error) {
+	x, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().kind == tokMaybe {
+		p.next()
+		y, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		x = maybe{x, y}
+	}
+	return x, nil
+}
+
+func (p *parser) parseOr() (Expr, error) {
+	x, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	xs := or{x}
+	for p.peek().kind == tokOr {
+		p.next()
+		y, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		xs = append(xs, y)
+	}
+	if len(xs) == 1 {
+		return x, nil
+	}
+	return xs, nil
+}
+
+func (p *parser) parseUnary() (Expr, error) {
+	if p.peek().kind == tokNot {
+		p.next()
+		x, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return not{x}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Expr, error) {
+	t := p.next()
+	switch t.kind {
+	case tokTerm:
+		return term(t.text), nil
+	case tokOpen:
+		x, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		if c := p.next(); c.kind != tokClose {
+			return nil, p.errorf(t, "missing ')' for '('")
+		}
+		return x, nil
+	}
+	return nil, p.errorf(t, "expected a term, found %s", t)
+}
@@ -0,0 +1,117 @@
+// Package query parses and evaluates the boolean queries used by the
+// "boolean" matching mode of a plucker.
+//
+// A query is a list of terms which must all be found in a plucked
+// occurrence. Terms are words or double-quoted phrases and are matched
+// as exact (case sensitive) substrings. The following operators are
+// supported, from the highest to the lowest precedence:
+//
+//   - NOT: `hello -world` or `hello !world`
+//   - OR: `hello | world`
+//   - MAYBE: `hello MAYBE world` (the right side is optional)
+//   - AND: `hello world`
+//
+// Parentheses group sub-expressions, e.g. `( hello | hi ) world`.
+package query
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// Expr is a compiled boolean query.
+type Expr interface {
+	// Match reports whether the text satisfies the expression.
+	Match(text []byte) bool
+	// String returns the expression in a canonical, fully parenthesized form.
+	String() string
+}
+
+// SyntaxError describes a malformed query.
+type SyntaxError struct {
+	Query  string // the query being parsed
+	Offset int    // byte offset of the error in the query
+	Msg    string // description of the problem
+}
+
+func (e *SyntaxError) Error() string {
+	return fmt.Sprintf("query %q: %s at offset %d", e.Query, e.Msg, e.Offset)
+}
+
+// Parse compiles a boolean query into an expression tree.
+func Parse(q string) (Expr, error) {
+	p := &parser{query: q}
+	if err := p.scan(); err != nil {
+		return nil, err
+	}
+	if p.peek().kind == tokEOF {
+		return nil, p.errorf(p.peek(), "empty query")
+	}
+	e, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != tokEOF {
+		return nil, p.errorf(t, "unexpected %s", t)
+	}
+	return e, nil
+}
+
+// MustParse is like Parse but panics if the query is malformed.
+func MustParse(q string) Expr {
+	e, err := Parse(q)
+	if err != nil {
+		panic(err)
+	}
+	return e
+}
+
+type term []byte
+
+func (t term) Match(text []byte) bool { return bytes.Contains(text, t) }
+func (t term) String() string         { return fmt.Sprintf("%q", string(t)) }
+
+type not struct{ x Expr }
+
+func (n not) Match(text []byte) bool { return !n.x.Match(text) }
+func (n not) String() string         { return "NOT " + n.x.String() }
+
+type or []Expr
+
+func (o or) Match(text []byte) bool {
+	for _, x := range o {
+		if x.Match(text) {
+			return true
+		}
+	}
+	return false
+}
+func (o or) String() string { return join(o, " OR ") }
+
+type and []Expr
+
+func (a and) Match(text []byte) bool {
+	for _, x := range a {
+		if !x.Match(text) {
+			return false
+		}
+	}
+	return true
+}
+func (a and) String() string { return join(a, " AND ") }
+
+// maybe matches like its left side: the right side only
+// documents an optional term.
+type maybe struct{ x, y Expr }
+
+func (m maybe) Match(text []byte) bool { return m.x.Match(text) }
+func (m maybe) String() string         { return "(" + m.x.String() + " MAYBE " + m.y.String() + ")" }
+
+func join(xs []Expr, sep string) string {
+	s := make([]string, len(xs))
+	for i, x := range xs {
+		s[i] = x.String()
+	}
+	return "(" + strings.Join(s, sep) + ")"
+}
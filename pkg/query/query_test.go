@@ -0,0 +1,82 @@
+package query
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParse(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected string
+	}{
+		{`hello`, `"hello"`},
+		{`hello world`, `("hello" AND "world")`},
+		{`hello | world`, `("hello" OR "world")`},
+		{`hello -world`, `("hello" AND NOT "world")`},
+		{`hello !world`, `("hello" AND NOT "world")`},
+		{`well-known`, `"well-known"`},
+		{`hello MAYBE world`, `("hello" MAYBE "world")`},
+		{`a b | c`, `("a" AND ("b" OR "c"))`},
+		{`( a b ) | c`, `(("a" AND "b") OR "c")`},
+		{`-(a | b) c`, `(NOT ("a" OR "b") AND "c")`},
+		{`"hello world" -"good bye"`, `("hello world" AND NOT "good bye")`},
+	} {
+		e, err := Parse(tc.query)
+		if assert.Nil(t, err, tc.query) {
+			assert.Equal(t, tc.expected, e.String(), tc.query)
+		}
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	for _, tc := range []struct {
+		query    string
+		expected string
+	}{
+		{``, `query "": empty query at offset 0`},
+		{`   `, `query "   ": empty query at offset 3`},
+		{`hello )`, `query "hello )": unexpected ')' at offset 6`},
+		{`( hello`, `query "( hello": missing ')' for '(' at offset 0`},
+		{`hello |`, `query "hello |": expected a term, found end of query at offset 7`},
+		{`| hello`, `query "| hello": expected a term, found '|' at offset 0`},
+		{`hello -`, `query "hello -": expected a term, found end of query at offset 7`},
+		{`hello MAYBE`, `query "hello MAYBE": expected a term, found end of query at offset 11`},
+		{`()`, `query "()": expected a term, found ')' at offset 1`},
+		{`say "hello`, `query "say \"hello": unterminated phrase at offset 4`},
+		{`say ""`, `query "say \"\"": empty phrase at offset 4`},
+	} {
+		_, err := Parse(tc.query)
+		if assert.NotNil(t, err, tc.query) {
+			assert.Equal(t, tc.expected, err.Error())
+			assert.IsType(t, &SyntaxError{}, err)
+		}
+	}
+}
+
+func TestMatch(t *testing.T) {
+	text := []byte("the quick brown fox jumps over the lazy dog")
+	for _, tc := range []struct {
+		query    string
+		expected bool
+	}{
+		{`fox`, true},
+		{`cat`, false},
+		{`fox dog`, true},
+		{`fox cat`, false},
+		{`cat | dog`, true},
+		{`cat | bird`, false},
+		{`fox -cat`, true},
+		{`fox !dog`, false},
+		{`fox MAYBE cat`, true},
+		{`cat MAYBE fox`, false},
+		{`(cat | fox) (bird | dog)`, true},
+		{`-(cat | bird)`, true},
+		{`"brown fox"`, true},
+		{`"fox brown"`, false},
+		{`Fox`, false},
+	} {
+		assert.Equal(t, tc.expected, MustParse(tc.query).Match(text), tc.query)
+	}
+}
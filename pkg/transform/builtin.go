@@ -0,0 +1,232 @@
+package transform
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	// external
+	"github.com/pkg/errors"
+)
+
+func init() {
+	Register("trim", newTrim)
+	Register("lowercase", Simple(strings.ToLower))
+	Register("uppercase", Simple(strings.ToUpper))
+	Register("collapse_whitespace", Simple(collapseWhitespace))
+	Register("regex_replace", newRegexReplace)
+	Register("url_resolve", newURLResolve)
+	Register("json_unescape", noArgs(jsonUnescape))
+	Register("base64_decode", newBase64Decode)
+	Register("number", newNumber)
+}
+
+// newTrim trims the white space around a value,
+// or the characters given as argument.
+func newTrim(args []string) (Transform, error) {
+	if err := checkArgs(args, 0, 1); err != nil {
+		return nil, err
+	}
+	return Func(func(value string, env *Env) (string, error) {
+		if len(args) == 0 {
+			return strings.TrimSpace(value), nil
+		}
+		return strings.Trim(value, args[0]), nil
+	}), nil
+}
+
+// collapseWhitespace replaces each sequence of white space by a
+// single space and trims the value.
+func collapseWhitespace(value string) string {
+	return strings.Join(strings.Fields(value), " ")
+}
+
+// newRegexReplace replaces the matches of the regular expression given
+// as first argument by the second argument, which can refer to the
+// submatches as $1, ${name}...
+func newRegexReplace(args []string) (Transform, error) {
+	if err := checkArgs(args, 2, 2); err != nil {
+		return nil, err
+	}
+	re, err := regexp.Compile(args[0])
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid regular expression")
+	}
+	return Func(func(value string, env *Env) (string, error) {
+		return re.ReplaceAllString(value, args[1]), nil
+	}), nil
+}
+
+// newURLResolve resolves a relative URL against the base URL given
+// as argument, or else the base URL of the input.
+func newURLResolve(args []string) (Transform, error) {
+	if err := checkArgs(args, 0, 1); err != nil {
+		return nil, err
+	}
+	var base *url.URL
+	if len(args) == 1 {
+		var err error
+		if base, err = url.Parse(args[0]); err != nil {
+			return nil, errors.Wrap(err, "invalid base URL")
+		}
+	}
+	return Func(func(value string, env *Env) (string, error) {
+		b := base
+		if b == nil && env != nil {
+			b = env.BaseURL
+		}
+		ref, err := url.Parse(strings.TrimSpace(value))
+		if err != nil {
+			return value, err
+		}
+		if b == nil {
+			if !ref.IsAbs() {
+				return value, errors.New("no base URL to resolve a relative URL")
+			}
+			return ref.String(), nil
+		}
+		return b.ResolveReference(ref).String(), nil
+	}), nil
+}
+
+// jsonUnescape decodes the escape sequences of a JSON string,
+// such as \n, \" or \u00e9.
+func jsonUnescape(value string) (string, error) {
+	var s string
+	if err := json.Unmarshal([]byte(`"`+value+`"`), &s); err != nil {
+		return value, errors.Wrap(err, "invalid JSON string")
+	}
+	return s, nil
+}
+
+// newBase64Decode decodes standard base64, padded or not, or
+// the URL-safe variant if the argument is "url".
+func newBase64Decode(args []string) (Transform, error) {
+	if err := checkArgs(args, 0, 1); err != nil {
+		return nil, err
+	}
+	padded, raw := base64.StdEncoding, base64.RawStdEncoding
+	if len(args) == 1 {
+		switch args[0] {
+		case "std":
+		case "url":
+			padded, raw = base64.URLEncoding, base64.RawURLEncoding
+		default:
+			return nil, errors.Errorf("unknown base64 encoding '%s'", args[0])
+		}
+	}
+	return Func(func(value string, env *Env) (string, error) {
+		value = strings.TrimSpace(value)
+		encoding := padded
+		if !strings.HasSuffix(value, "=") {
+			encoding = raw
+		}
+		b, err := encoding.DecodeString(value)
+		if err != nil {
+			return value, errors.Wrap(err, "invalid base64")
+		}
+		return string(b), nil
+	}), nil
+}
+
+// newNumber extracts the number of a value such as "$ 1,234.50",
+// giving "1234.5". The argument is the decimal separator, "." by
+// default: the other one of "." and "," separates the thousands.
+func newNumber(args []string) (Transform, error) {
+	if err := checkArgs(args, 0, 1); err != nil {
+		return nil, err
+	}
+	decimal := '.'
+	if len(args) == 1 {
+		switch args[0] {
+		case ".":
+		case ",":
+			decimal = ','
+		default:
+			return nil, errors.Errorf("unknown decimal separator '%s'", args[0])
+		}
+	}
+	return Func(func(value string, env *Env) (string, error) {
+		number, err := Number(value, decimal)
+		if err != nil {
+			return value, err
+		}
+		n, err := strconv.ParseFloat(number, 64)
+		if err != nil {
+			return value, errors.Errorf("no number in '%s'", value)
+		}
+		return strconv.FormatFloat(n, 'f', -1, 64), nil
+	}), nil
+}
+
+// Number returns the first number found in a value, without its
+// thousands separators and the white space inside it, and with "."
+// as decimal separator: "$ -1,234.50" gives "-1234.50". The decimal
+// separator of the value is "." or ",", the other one separating
+// the thousands. A hyphen is a minus sign only right before the
+// number and after no letter or digit, so "SKU-1234" gives "1234".
+// An exponent right after a digit is part of the number: "1.5e3 m"
+// gives "1.5e3".
+func Number(value string, decimal rune) (string, error) {
+	thousands := ','
+	if decimal == ',' {
+		thousands = '.'
+	}
+	var b strings.Builder
+	started, exponent := false, false
+	runes := []rune(value)
+scan:
+	for i := 0; i < len(runes); i++ {
+		r := runes[i]
+		switch {
+		case unicode.IsDigit(r):
+			b.WriteRune(r)
+			started = true
+		case exponent:
+			break scan
+		case (r == 'e' || r == 'E') && i > 0 && unicode.IsDigit(runes[i-1]) && isExponent(runes, i):
+			b.WriteByte('e')
+			if runes[i+1] == '-' || runes[i+1] == '+' {
+				i++
+				b.WriteRune(runes[i])
+			}
+			exponent = true
+		case r == decimal:
+			b.WriteByte('.')
+			started = true
+		case r == '-' && !started && isSign(runes, i, decimal):
+			b.Reset()
+			b.WriteByte('-')
+		case started && (r == thousands || r == ' ' || r == '\u00a0' || r == '\''):
+		case started:
+			break scan
+		}
+	}
+	s := strings.TrimSuffix(b.String(), ".")
+	if s == "" || s == "-" || s == "." {
+		return "", errors.Errorf("no number in '%s'", value)
+	}
+	return s, nil
+}
+
+// isExponent reports whether the "e" at the index i of runes starts an
+// exponent: it is followed by digits, possibly after a sign
+func isExponent(runes []rune, i int) bool {
+	if i+1 < len(runes) && (runes[i+1] == '-' || runes[i+1] == '+') {
+		i++
+	}
+	return i+1 < len(runes) && unicode.IsDigit(runes[i+1])
+}
+
+// isSign reports whether the hyphen at the index i of runes is a minus sign:
+// it starts a number, and does not join words as in "SKU-1234" or "e-mail"
+func isSign(runes []rune, i int, decimal rune) bool {
+	if i+1 == len(runes) || !unicode.IsDigit(runes[i+1]) && runes[i+1] != decimal {
+		return false
+	}
+	return i == 0 || !unicode.IsLetter(runes[i-1]) && !unicode.IsDigit(runes[i-1])
+}
@@ -0,0 +1,125 @@
+package transform
+
+import (
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuiltins(t *testing.T) {
+	base, _ := url.Parse("http://example.com/songs/index.html")
+	env := &Env{BaseURL: base}
+	for _, test := range []struct {
+		name  string
+		args  []string
+		value string
+		want  string
+	}{
+		{"trim", nil, " \t a b \n", "a b"},
+		{"trim", []string{"*-"}, "-*a b*-", "a b"},
+		{"lowercase", nil, "HeLLo", "hello"},
+		{"uppercase", nil, "HeLLo", "HELLO"},
+		{"collapse_whitespace", nil, "  a \n\t b  c ", "a b c"},
+		{"regex_replace", []string{`(\d+)-(\d+)`, "$2-$1"}, "1-2 and 3-4", "2-1 and 4-3"},
+		{"url_resolve", nil, "../about.html", "http://example.com/about.html"},
+		{"url_resolve", nil, " /img/a.png ", "http://example.com/img/a.png"},
+		{"url_resolve", nil, "https://other.org/x", "https://other.org/x"},
+		{"url_resolve", []string{"https://cdn.org/static/"}, "a.png", "https://cdn.org/static/a.png"},
+		{"json_unescape", nil, `a\nb \"c\" é\/`, "a\nb \"c\" é/"},
+		{"base64_decode", nil, "aGVsbG8=", "hello"},
+		{"base64_decode", nil, "aGVsbG8", "hello"},
+		{"base64_decode", []string{"url"}, "Pz8-", "??>"},
+		{"number", nil, "$ 1,234.50", "1234.5"},
+		{"number", nil, "Price: -12 EUR", "-12"},
+		{"number", nil, "42", "42"},
+		{"number", []string{","}, "1.234,5 €", "1234.5"},
+		{"number", []string{","}, "1 234 567,00", "1234567"},
+	} {
+		tr, err := New(test.name, test.args...)
+		if !assert.Nil(t, err, test.name) {
+			continue
+		}
+		value, err := tr.Apply(test.value, env)
+		assert.Nil(t, err, "%s %q", test.name, test.value)
+		assert.Equal(t, test.want, value, "%s %q", test.name, test.value)
+	}
+}
+
+func TestBuiltinErrors(t *testing.T) {
+	for _, test := range []struct {
+		name  string
+		args  []string
+		value string
+		err   string
+	}{
+		{"url_resolve", nil, "about.html", "no base URL to resolve a relative URL"},
+		{"json_unescape", nil, `a\qb`, "invalid JSON string: "},
+		{"base64_decode", nil, "a!b", "invalid base64: "},
+		{"number", nil, "none", "no number in 'none'"},
+	} {
+		tr, err := New(test.name, test.args...)
+		if !assert.Nil(t, err, test.name) {
+			continue
+		}
+		value, err := tr.Apply(test.value, nil)
+		if assert.NotNil(t, err, test.name) {
+			// the errors of the standard library differ between versions
+			assert.True(t, strings.HasPrefix(err.Error(), test.err), err.Error())
+		}
+		assert.Equal(t, test.value, value, test.name)
+	}
+
+	for _, test := range []struct {
+		name string
+		args []string
+		err  string
+	}{
+		{"regex_replace", []string{"(", ""}, "transform regex_replace: invalid regular expression: "},
+		{"url_resolve", []string{":"}, "transform url_resolve: invalid base URL: "},
+		{"base64_decode", []string{"hex"}, "transform base64_decode: unknown base64 encoding 'hex'"},
+		{"number", []string{";"}, "transform number: unknown decimal separator ';'"},
+	} {
+		_, err := New(test.name, test.args...)
+		if assert.NotNil(t, err, test.name) {
+			assert.True(t, strings.HasPrefix(err.Error(), test.err), err.Error())
+		}
+	}
+}
+
+func TestNumber(t *testing.T) {
+	for _, test := range []struct {
+		value   string
+		decimal rune
+		want    string
+	}{
+		{"$ -1,234.50", '.', "-1234.50"},
+		{"1.234,5 €", ',', "1234.5"},
+		{"12 items, 3 left", '.', "12"},
+		{"7.", '.', "7"},
+		{"-.5", '.', "-.5"},
+		{"(-3)", '.', "-3"},
+		// a hyphen which is not a minus sign
+		{"SKU-1234", '.', "1234"},
+		{"Rating - 5 stars", '.', "5"},
+		{"e-mail: 3", '.', "3"},
+		{"pages 10-12", '.', "10"},
+		// an exponent
+		{"1.5e3 m", '.', "1.5e3"},
+		{"2E-3", '.', "2e-3"},
+		{"1,5e+2", ',', "1.5e+2"},
+		{"3 e5", '.', "3"},
+		{"4e", '.', "4"},
+		{"5e-x", '.', "5"},
+		{"1e5,000", '.', "1e5"},
+	} {
+		number, err := Number(test.value, test.decimal)
+		assert.Nil(t, err)
+		assert.Equal(t, test.want, number, test.value)
+	}
+	for _, value := range []string{"", "none", "-", "."} {
+		_, err := Number(value, '.')
+		assert.EqualError(t, err, "no number in '"+value+"'")
+	}
+}
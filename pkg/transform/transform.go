@@ -0,0 +1,124 @@
+// Package transform shapes the captures of a plucker once they are
+// found. A transform is created by name, with optional arguments, from
+// a registry holding the built-in transforms, which can be extended
+// with custom ones:
+//
+//	transform.Register("reverse", transform.Simple(func(s string) string {
+//		r := []rune(s)
+//		for i, j := 0, len(r)-1; i < j; i, j = i+1, j-1 {
+//			r[i], r[j] = r[j], r[i]
+//		}
+//		return string(r)
+//	}))
+package transform
+
+import (
+	"net/url"
+	"sort"
+	"sync"
+
+	// external
+	"github.com/pkg/errors"
+)
+
+// Transform shapes a capture.
+type Transform interface {
+	// Apply returns the transformed value of a capture, or an error if
+	// the capture cannot be transformed.
+	Apply(value string, env *Env) (string, error)
+}
+
+// Env describes the input the captures come from.
+type Env struct {
+	// BaseURL is the URL relative URLs are resolved against, if known.
+	BaseURL *url.URL
+}
+
+// Func adapts a function to the Transform interface.
+type Func func(value string, env *Env) (string, error)
+
+// Apply calls f.
+func (f Func) Apply(value string, env *Env) (string, error) {
+	return f(value, env)
+}
+
+// Factory creates a transform from its arguments.
+type Factory func(args []string) (Transform, error)
+
+var (
+	registryMu sync.RWMutex
+	registry   = make(map[string]Factory)
+)
+
+// Register makes a transform available under the given name. It panics
+// if the name is empty or already registered.
+func Register(name string, factory Factory) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	if name == "" || factory == nil {
+		panic("transform: Register needs a name and a factory")
+	}
+	if _, dup := registry[name]; dup {
+		panic("transform: Register called twice for " + name)
+	}
+	registry[name] = factory
+}
+
+// New returns the transform registered under the given name,
+// created with the given arguments.
+func New(name string, args ...string) (Transform, error) {
+	registryMu.RLock()
+	factory, ok := registry[name]
+	registryMu.RUnlock()
+	if !ok {
+		return nil, errors.Errorf("unknown transform '%s'", name)
+	}
+	t, err := factory(args)
+	if err != nil {
+		return nil, errors.Wrapf(err, "transform %s", name)
+	}
+	return t, nil
+}
+
+// Names returns the sorted names of the registered transforms.
+func Names() []string {
+	registryMu.RLock()
+	defer registryMu.RUnlock()
+	names := make([]string, 0, len(registry))
+	for name := range registry {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Simple returns a factory of transforms without arguments
+// which cannot fail.
+func Simple(f func(string) string) Factory {
+	return noArgs(func(value string) (string, error) {
+		return f(value), nil
+	})
+}
+
+// noArgs returns a factory of transforms without arguments.
+func noArgs(f func(string) (string, error)) Factory {
+	return func(args []string) (Transform, error) {
+		if err := checkArgs(args, 0, 0); err != nil {
+			return nil, err
+		}
+		return Func(func(value string, env *Env) (string, error) {
+			return f(value)
+		}), nil
+	}
+}
+
+// checkArgs checks that there are between min and max arguments.
+func checkArgs(args []string, min, max int) error {
+	if len(args) < min || len(args) > max {
+		if min == max {
+			return errors.Errorf("expects %d argument(s), got %d", min, len(args))
+		}
+		return errors.Errorf("expects %d to %d arguments, got %d", min, max, len(args))
+	}
+	return nil
+}
@@ -0,0 +1,58 @@
+package transform
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	tr, err := New("lowercase")
+	assert.Nil(t, err)
+	value, err := tr.Apply("Hello", nil)
+	assert.Nil(t, err)
+	assert.Equal(t, "hello", value)
+
+	_, err = New("missing")
+	assert.EqualError(t, err, "unknown transform 'missing'")
+
+	_, err = New("lowercase", "extra")
+	assert.EqualError(t, err, "transform lowercase: expects 0 argument(s), got 1")
+
+	_, err = New("regex_replace", "a")
+	assert.EqualError(t, err, "transform regex_replace: expects 2 argument(s), got 1")
+
+	_, err = New("trim", "a", "b")
+	assert.EqualError(t, err, "transform trim: expects 0 to 1 arguments, got 2")
+}
+
+func TestRegister(t *testing.T) {
+	Register("test_repeat", func(args []string) (Transform, error) {
+		if err := checkArgs(args, 1, 1); err != nil {
+			return nil, err
+		}
+		return Func(func(value string, env *Env) (string, error) {
+			return value + args[0] + value, nil
+		}), nil
+	})
+	assert.Contains(t, Names(), "test_repeat")
+	tr, err := New("test_repeat", "-")
+	assert.Nil(t, err)
+	value, _ := tr.Apply("a", nil)
+	assert.Equal(t, "a-a", value)
+
+	assert.Panics(t, func() { Register("test_repeat", Simple(strings.TrimSpace)) })
+	assert.Panics(t, func() { Register("", Simple(strings.TrimSpace)) })
+	assert.Panics(t, func() { Register("test_nil", nil) })
+}
+
+func TestNames(t *testing.T) {
+	names := Names()
+	for _, name := range []string{"trim", "lowercase", "collapse_whitespace", "regex_replace", "url_resolve", "json_unescape", "base64_decode", "number"} {
+		assert.Contains(t, names, name)
+	}
+	for i := 1; i < len(names); i++ {
+		assert.True(t, names[i-1] < names[i])
+	}
+}